@@ -0,0 +1,460 @@
+// Package agentlenstest provides an in-memory AgentLens server for tests.
+//
+//	fake := agentlenstest.NewFakeServer()
+//	defer fake.Close()
+//	client := agentlens.NewClient(fake.URL, "key")
+//	// ... exercise code that logs through client ...
+//	events := fake.Events()
+package agentlenstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	agentlens "github.com/agentkitai/agentlens-go"
+)
+
+// Page sizes used by the real server.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// FakeServer is an httptest server implementing the AgentLens event
+// ingestion, event query and session endpoints in memory, including
+// per-session hash chaining, and guardrail rule CRUD. It accepts any API
+// key. Other endpoints return 404.
+type FakeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	events   []agentlens.Event
+	sessions map[string]*session
+	seq      int
+
+	// Guardrail rules are kept as JSON objects so updates merge field by
+	// field, as on the server.
+	rules   map[string]map[string]any
+	ruleSeq int
+}
+
+// session mirrors the server's session shape, materialized from events.
+type session struct {
+	ID            string   `json:"id"`
+	AgentID       string   `json:"agentId"`
+	StartedAt     string   `json:"startedAt"`
+	EndedAt       *string  `json:"endedAt,omitempty"`
+	Status        string   `json:"status"`
+	EventCount    int      `json:"eventCount"`
+	ToolCallCount int      `json:"toolCallCount"`
+	ErrorCount    int      `json:"errorCount"`
+	LlmCallCount  int      `json:"llmCallCount"`
+	TotalCostUsd  float64  `json:"totalCostUsd"`
+	Tags          []string `json:"tags"`
+
+	lastHash string
+}
+
+// NewFakeServer starts a FakeServer. Call Close when done.
+func NewFakeServer() *FakeServer {
+	f := &FakeServer{sessions: map[string]*session{}, rules: map[string]map[string]any{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	return f
+}
+
+// Events returns a copy of every ingested event, in ingestion order.
+func (f *FakeServer) Events() []agentlens.Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]agentlens.Event(nil), f.events...)
+}
+
+// Reset discards all events, sessions and guardrail rules.
+func (f *FakeServer) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = nil
+	f.sessions = map[string]*session{}
+	f.rules = map[string]map[string]any{}
+}
+
+func (f *FakeServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/api/health" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "version": "fake"})
+	case path == "/api/events" && r.Method == http.MethodPost:
+		f.ingest(w, r)
+	case path == "/api/events" && r.Method == http.MethodGet:
+		f.queryEvents(w, r)
+	case strings.HasPrefix(path, "/api/events/") && r.Method == http.MethodGet:
+		f.getEvent(w, strings.TrimPrefix(path, "/api/events/"))
+	case path == "/api/sessions" && r.Method == http.MethodGet:
+		f.querySessions(w, r)
+	case strings.HasPrefix(path, "/api/sessions/") && strings.HasSuffix(path, "/timeline") && r.Method == http.MethodGet:
+		f.timeline(w, strings.TrimSuffix(strings.TrimPrefix(path, "/api/sessions/"), "/timeline"))
+	case strings.HasPrefix(path, "/api/sessions/") && r.Method == http.MethodGet:
+		f.getSession(w, strings.TrimPrefix(path, "/api/sessions/"))
+	case path == "/api/guardrails" && r.Method == http.MethodPost:
+		f.createRule(w, r)
+	case path == "/api/guardrails" && r.Method == http.MethodGet:
+		f.listRules(w, r)
+	case strings.HasPrefix(path, "/api/guardrails/"):
+		f.rule(w, r, strings.TrimPrefix(path, "/api/guardrails/"))
+	default:
+		writeError(w, http.StatusNotFound, "Not found", nil)
+	}
+}
+
+func (f *FakeServer) ingest(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Events []agentlens.Event `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body", nil)
+		return
+	}
+	if len(body.Events) == 0 {
+		writeError(w, http.StatusBadRequest, "Validation failed", []agentlens.FieldError{{Field: "events", Message: "at least one event is required"}})
+		return
+	}
+	for i, e := range body.Events {
+		var missing string
+		switch {
+		case e.SessionID == "":
+			missing = "sessionId"
+		case e.AgentID == "":
+			missing = "agentId"
+		case e.EventType == "":
+			missing = "eventType"
+		}
+		if missing != "" {
+			writeError(w, http.StatusBadRequest, "Validation failed", []agentlens.FieldError{{Field: fmt.Sprintf("events.%d.%s", i, missing), Message: "Required"}})
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	type ingested struct {
+		ID   string `json:"id"`
+		Hash string `json:"hash"`
+	}
+	out := make([]ingested, 0, len(body.Events))
+	for _, e := range body.Events {
+		f.seq++
+		e.ID = fmt.Sprintf("evt_%06d", f.seq)
+		if e.Timestamp == "" {
+			e.Timestamp = time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+		}
+		if e.Severity == "" {
+			e.Severity = "info"
+		}
+		if e.Payload == nil {
+			e.Payload = map[string]any{}
+		}
+		if e.Metadata == nil {
+			e.Metadata = map[string]any{}
+		}
+		s := f.session(e)
+		e.PrevHash = nil
+		if s.lastHash != "" {
+			prev := s.lastHash
+			e.PrevHash = &prev
+		}
+		hash := agentlens.DefaultEventHash(e, s.lastHash)
+		e.Hash = &hash
+		s.lastHash = hash
+		f.events = append(f.events, e)
+		out = append(out, ingested{ID: e.ID, Hash: hash})
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"ingested": len(out), "events": out})
+}
+
+// session returns e's session, creating it on first use, and updates its
+// counters for e. f.mu must be held.
+func (f *FakeServer) session(e agentlens.Event) *session {
+	s, ok := f.sessions[e.SessionID]
+	if !ok {
+		s = &session{ID: e.SessionID, AgentID: e.AgentID, StartedAt: e.Timestamp, Status: "active", Tags: []string{}}
+		f.sessions[e.SessionID] = s
+	}
+	s.EventCount++
+	switch e.EventType {
+	case "tool_call":
+		s.ToolCallCount++
+	case "llm_call":
+		s.LlmCallCount++
+	case "cost_tracked", "llm_response":
+		if cost, ok := e.Payload["costUsd"].(float64); ok {
+			s.TotalCostUsd += cost
+		}
+	case "session_ended":
+		ended := e.Timestamp
+		s.EndedAt = &ended
+		s.Status = "completed"
+	}
+	if e.Severity == "error" || e.Severity == "critical" || e.EventType == "tool_error" {
+		s.ErrorCount++
+	}
+	return s
+}
+
+func (f *FakeServer) queryEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	match := func(e agentlens.Event) bool {
+		return matchOne(q.Get("sessionId"), e.SessionID) &&
+			matchOne(q.Get("agentId"), e.AgentID) &&
+			matchAny(q.Get("eventType"), e.EventType) &&
+			matchAny(q.Get("severity"), e.Severity) &&
+			(q.Get("from") == "" || e.Timestamp >= q.Get("from")) &&
+			(q.Get("to") == "" || e.Timestamp <= q.Get("to"))
+	}
+	f.mu.Lock()
+	var events []agentlens.Event
+	for _, e := range f.events {
+		if match(e) {
+			events = append(events, e)
+		}
+	}
+	f.mu.Unlock()
+
+	asc := q.Get("order") == "asc"
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Timestamp != events[j].Timestamp {
+			return (events[i].Timestamp < events[j].Timestamp) == asc
+		}
+		return (events[i].ID < events[j].ID) == asc
+	})
+	page, hasMore := paginate(len(events), q)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"events":  nonNil(events[page[0]:page[1]]),
+		"total":   len(events),
+		"hasMore": hasMore,
+	})
+}
+
+func (f *FakeServer) getEvent(w http.ResponseWriter, id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.events {
+		if e.ID == id {
+			writeJSON(w, http.StatusOK, e)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Event not found", nil)
+}
+
+func (f *FakeServer) querySessions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f.mu.Lock()
+	var sessions []session
+	for _, s := range f.sessions {
+		if matchOne(q.Get("agentId"), s.AgentID) && matchAny(q.Get("status"), s.Status) {
+			sessions = append(sessions, *s)
+		}
+	}
+	f.mu.Unlock()
+
+	// Newest first, as on the server.
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].StartedAt != sessions[j].StartedAt {
+			return sessions[i].StartedAt > sessions[j].StartedAt
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+	page, hasMore := paginate(len(sessions), q)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"sessions": nonNil(sessions[page[0]:page[1]]),
+		"total":    len(sessions),
+		"hasMore":  hasMore,
+	})
+}
+
+func (f *FakeServer) getSession(w http.ResponseWriter, id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.sessions[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Session not found", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+func (f *FakeServer) timeline(w http.ResponseWriter, id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.sessions[id]; !ok {
+		writeError(w, http.StatusNotFound, "Session not found", nil)
+		return
+	}
+	events := []agentlens.Event{}
+	for _, e := range f.events {
+		if e.SessionID == id {
+			events = append(events, e)
+		}
+	}
+	// Events are stored in chain order, which is what the chain is verified in.
+	valid := true
+	prev := ""
+	for _, e := range events {
+		if derefString(e.PrevHash) != prev || agentlens.DefaultEventHash(e, prev) != derefString(e.Hash) {
+			valid = false
+			break
+		}
+		prev = *e.Hash
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"events": events, "chainValid": valid})
+}
+
+func (f *FakeServer) createRule(w http.ResponseWriter, r *http.Request) {
+	var rule map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body", nil)
+		return
+	}
+	for _, field := range []string{"name", "conditionType", "conditionConfig", "actionType", "actionConfig"} {
+		if rule[field] == nil {
+			writeError(w, http.StatusBadRequest, "Validation failed", []agentlens.FieldError{{Field: field, Message: "Required"}})
+			return
+		}
+	}
+	defaults := map[string]any{"enabled": true, "dryRun": true, "cooldownMinutes": 15}
+	for k, v := range defaults {
+		if _, ok := rule[k]; !ok {
+			rule[k] = v
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ruleSeq++
+	now := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	rule["id"] = fmt.Sprintf("rule_%06d", f.ruleSeq)
+	rule["createdAt"], rule["updatedAt"] = now, now
+	f.rules[rule["id"].(string)] = rule
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+func (f *FakeServer) listRules(w http.ResponseWriter, r *http.Request) {
+	agentID := r.URL.Query().Get("agentId")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rules := []map[string]any{}
+	for _, rule := range f.rules {
+		if id, _ := rule["agentId"].(string); agentID == "" || id == agentID {
+			rules = append(rules, rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i]["id"].(string) < rules[j]["id"].(string) })
+	writeJSON(w, http.StatusOK, map[string]any{"rules": rules})
+}
+
+// rule serves GET, PUT and DELETE /api/guardrails/{id}. PUT merges the
+// fields sent into the rule, leaving the others unchanged.
+func (f *FakeServer) rule(w http.ResponseWriter, r *http.Request, id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rule, ok := f.rules[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Guardrail rule not found", nil)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, rule)
+	case http.MethodPut:
+		var updates map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid JSON body", nil)
+			return
+		}
+		for k, v := range updates {
+			if k != "id" && k != "createdAt" {
+				rule[k] = v
+			}
+		}
+		rule["updatedAt"] = time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+		writeJSON(w, http.StatusOK, rule)
+	case http.MethodDelete:
+		delete(f.rules, id)
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	default:
+		writeError(w, http.StatusNotFound, "Not found", nil)
+	}
+}
+
+// paginate returns the [start, end) bounds of the page selected by the limit
+// and offset query parameters, and whether more items follow.
+func paginate(n int, q map[string][]string) ([2]int, bool) {
+	limit := defaultPageSize
+	if v, err := strconv.Atoi(first(q["limit"])); err == nil {
+		limit = min(max(v, 1), maxPageSize)
+	}
+	offset := 0
+	if v, err := strconv.Atoi(first(q["offset"])); err == nil && v > 0 {
+		offset = v
+	}
+	start := min(offset, n)
+	end := min(start+limit, n)
+	return [2]int{start, end}, end < n
+}
+
+func first(vals []string) string {
+	if len(vals) == 0 {
+		return ""
+	}
+	return vals[0]
+}
+
+func matchOne(want, got string) bool { return want == "" || want == got }
+
+// matchAny matches a comma-separated filter, as the server does for
+// eventType, severity and status.
+func matchAny(want, got string) bool {
+	if want == "" {
+		return true
+	}
+	for _, w := range strings.Split(want, ",") {
+		if w == got {
+			return true
+		}
+	}
+	return false
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string, details any) {
+	body := map[string]any{"error": message}
+	if details != nil {
+		body["details"] = details
+	}
+	writeJSON(w, status, body)
+}
@@ -0,0 +1,123 @@
+package agentlenstest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	agentlens "github.com/agentkitai/agentlens-go"
+)
+
+func TestFakeServer(t *testing.T) {
+	fake := NewFakeServer()
+	defer fake.Close()
+	c := agentlens.NewClient(fake.URL, "key")
+	ctx := context.Background()
+
+	completion := "hi"
+	if _, err := c.LogLlmCall(ctx, "s1", "a1", &agentlens.LogLlmCallParams{
+		Provider: "openai", Model: "gpt-4o", Completion: &completion,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.LogEvent(ctx, "s2", "a1", "custom", "warn", map[string]any{"type": "note", "data": map[string]any{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	events := fake.Events()
+	if len(events) != 3 || events[0].EventType != "llm_call" || events[1].EventType != "llm_response" {
+		t.Fatalf("unexpected ingested events: %+v", events)
+	}
+
+	warn := "warn"
+	res, err := c.QueryEvents(ctx, &agentlens.EventQuery{Severity: &warn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Events[0].SessionID != "s2" {
+		t.Errorf("severity filter: got %+v", res)
+	}
+
+	tl, err := c.GetSessionTimeline(ctx, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tl.Events) != 2 || !tl.ChainValid {
+		t.Fatalf("unexpected timeline: %+v", tl)
+	}
+	if tl.Events[1].PrevHash == nil || *tl.Events[1].PrevHash != *tl.Events[0].Hash {
+		t.Error("expected events to be hash-chained")
+	}
+	if breaks, err := tl.RecomputeChain(nil); err != nil || len(breaks) != 0 {
+		t.Errorf("RecomputeChain: %v, %v", breaks, err)
+	}
+
+	sessions, err := c.GetSessions(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessions.Total != 2 {
+		t.Errorf("expected 2 sessions, got %d", sessions.Total)
+	}
+	s, err := c.GetSession(ctx, "s1")
+	if err != nil || s.AgentID != "a1" || s.Status != "active" {
+		t.Errorf("GetSession: %+v, %v", s, err)
+	}
+
+	var nf *agentlens.NotFoundError
+	if _, err := c.GetSession(ctx, "missing"); !errors.As(err, &nf) {
+		t.Errorf("expected NotFoundError, got %v", err)
+	}
+}
+
+func TestFakeServerValidation(t *testing.T) {
+	fake := NewFakeServer()
+	defer fake.Close()
+	c := agentlens.NewClient(fake.URL, "key")
+
+	err := c.SendEvents(context.Background(), []agentlens.Event{{SessionID: "s1", EventType: "custom"}})
+	var ve *agentlens.ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(fake.Events()) != 0 {
+		t.Error("invalid batch should not be ingested")
+	}
+}
+
+func TestFakeServerGuardrailPartialUpdate(t *testing.T) {
+	fake := NewFakeServer()
+	defer fake.Close()
+	c := agentlens.NewClient(fake.URL, "key")
+	ctx := context.Background()
+
+	rule, err := c.CreateGuardrail(ctx, &agentlens.CreateGuardrailParams{
+		Name:            "errors",
+		ConditionType:   "error_rate_threshold",
+		ConditionConfig: map[string]any{"threshold": 0.2, "windowMinutes": 5.0},
+		ActionType:      "pause_agent",
+		ActionConfig:    map[string]any{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "error spike"
+	if _, err := c.UpdateGuardrail(ctx, rule.ID, &agentlens.UpdateGuardrailParams{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.DisableGuardrail(ctx, rule.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.GetGuardrail(ctx, rule.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != name || got.Enabled {
+		t.Errorf("updates not applied: %+v", got)
+	}
+	if got.ConditionConfig["threshold"] != 0.2 || got.ConditionConfig["windowMinutes"] != 5.0 || got.ActionType != "pause_agent" {
+		t.Errorf("partial updates clobbered other fields: %+v", got)
+	}
+}
@@ -0,0 +1,165 @@
+package agentlens
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidSignature is returned when a VerificationReport signature is
+// missing or does not match the report contents.
+var ErrInvalidSignature = errors.New("agentlens: invalid audit report signature")
+
+// signaturePrefix marks the server's HMAC-SHA256 report signatures.
+const signaturePrefix = "hmac-sha256:"
+
+// SignedPayload returns the canonical bytes the server signs for a report:
+// the report without its signature encoded as JSON, with keys in the
+// server's order and sessionId present only for single-session reports:
+//
+//	{"verified":true,"verifiedAt":"...","range":{"from":"...","to":"..."},"sessionsVerified":2,"totalEvents":1500,"firstHash":"abc123","lastHash":"def456","brokenChains":[]}
+func (r *VerificationReport) SignedPayload() []byte {
+	brokenChains := r.BrokenChains
+	if brokenChains == nil {
+		brokenChains = []BrokenChainDetail{}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // match JSON.stringify
+	err := enc.Encode(struct {
+		Verified         bool                `json:"verified"`
+		VerifiedAt       string              `json:"verifiedAt"`
+		Range            *VerificationRange  `json:"range"`
+		SessionID        *string             `json:"sessionId,omitempty"`
+		SessionsVerified int                 `json:"sessionsVerified"`
+		TotalEvents      int                 `json:"totalEvents"`
+		FirstHash        *string             `json:"firstHash"`
+		LastHash         *string             `json:"lastHash"`
+		BrokenChains     []BrokenChainDetail `json:"brokenChains"`
+	}{r.Verified, r.VerifiedAt, r.Range, r.SessionID, r.SessionsVerified, r.TotalEvents, r.FirstHash, r.LastHash, brokenChains})
+	if err != nil {
+		return nil
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// VerifySignature checks the report's Signature, "hmac-sha256:" followed by
+// the hex HMAC-SHA256 of SignedPayload, against the server's audit signing
+// key (AGENTLENS_AUDIT_SIGNING_KEY). Returns ErrInvalidSignature if the
+// signature is missing, malformed, or does not verify.
+func (r *VerificationReport) VerifySignature(key []byte) error {
+	if r.Signature == nil || !strings.HasPrefix(*r.Signature, signaturePrefix) || len(key) == 0 {
+		return ErrInvalidSignature
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(*r.Signature, signaturePrefix))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(r.SignedPayload())
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// ErrNoHashChain is returned by RecomputeChain when the timeline's events
+// carry no hash data to verify.
+var ErrNoHashChain = errors.New("agentlens: timeline events have no hashes")
+
+// eventHashVersion is the server's hash input format version.
+const eventHashVersion = 2
+
+// DefaultEventHash computes an event hash using the server's algorithm: the
+// lowercase hex SHA-256 of the JSON object
+//
+//	{"v":2,"id","timestamp","sessionId","agentId","eventType","severity","payload","metadata","prevHash"}
+//
+// with fields in that order, absent payload/metadata encoded as {}, and
+// prevHash null for the first event. Go encodes payload and metadata keys in
+// sorted order; events whose payloads were serialized with a different key
+// order need a custom hashFn.
+func DefaultEventHash(e Event, prev string) string {
+	var prevHash *string
+	if prev != "" {
+		prevHash = &prev
+	}
+	payload, metadata := e.Payload, e.Metadata
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // match JSON.stringify
+	err := enc.Encode(struct {
+		V         int            `json:"v"`
+		ID        string         `json:"id"`
+		Timestamp string         `json:"timestamp"`
+		SessionID string         `json:"sessionId"`
+		AgentID   string         `json:"agentId"`
+		EventType string         `json:"eventType"`
+		Severity  string         `json:"severity"`
+		Payload   map[string]any `json:"payload"`
+		Metadata  map[string]any `json:"metadata"`
+		PrevHash  *string        `json:"prevHash"`
+	}{eventHashVersion, e.ID, e.Timestamp, e.SessionID, e.AgentID, e.EventType, e.Severity, payload, metadata, prevHash})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// RecomputeChain independently verifies the timeline's hash chain. For each
+// event it checks that PrevHash links to the previous event's Hash and that
+// Hash matches hashFn(event, prevHash). A nil hashFn uses DefaultEventHash.
+// It returns one BrokenChainDetail per failing event, or ErrNoHashChain if
+// no event carries a hash.
+func (t *TimelineResult) RecomputeChain(hashFn func(Event, string) string) ([]BrokenChainDetail, error) {
+	if hashFn == nil {
+		hashFn = DefaultEventHash
+	}
+	hasHashes := false
+	for _, e := range t.Events {
+		if e.Hash != nil {
+			hasHashes = true
+			break
+		}
+	}
+	if !hasHashes {
+		return nil, ErrNoHashChain
+	}
+
+	var broken []BrokenChainDetail
+	fail := func(i int, e Event, reason string) {
+		broken = append(broken, BrokenChainDetail{SessionID: e.SessionID, FailedAtIndex: i, FailedEventID: e.ID, Reason: reason})
+	}
+	prev := ""
+	for i, e := range t.Events {
+		switch {
+		case derefString(e.PrevHash) != prev:
+			fail(i, e, fmt.Sprintf("prevHash %q does not match previous hash %q", derefString(e.PrevHash), prev))
+		case e.Hash == nil:
+			fail(i, e, "missing hash")
+		case *e.Hash != hashFn(e, prev):
+			fail(i, e, "hash does not match event contents")
+		}
+		prev = derefString(e.Hash)
+	}
+	return broken, nil
+}
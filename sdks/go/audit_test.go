@@ -0,0 +1,169 @@
+package agentlens
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testSigningKey = "audit-signing-key"
+
+// signReport mirrors the server's signReport: an HMAC-SHA256 of the report
+// JSON without its signature.
+func signReport(r *VerificationReport, key string) {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(r.SignedPayload())
+	sig := "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+	r.Signature = &sig
+}
+
+func testReport() VerificationReport {
+	first, last := "aaa", "bbb"
+	return VerificationReport{
+		Verified:         true,
+		VerifiedAt:       "2024-02-01T00:00:01.000Z",
+		Range:            &VerificationRange{From: "2024-01-01T00:00:00Z", To: "2024-02-01T00:00:00Z"},
+		SessionsVerified: 2,
+		TotalEvents:      1500,
+		FirstHash:        &first,
+		LastHash:         &last,
+	}
+}
+
+func TestVerifySignatureRoundTrip(t *testing.T) {
+	r := testReport()
+	want := `{"verified":true,"verifiedAt":"2024-02-01T00:00:01.000Z","range":{"from":"2024-01-01T00:00:00Z","to":"2024-02-01T00:00:00Z"},"sessionsVerified":2,"totalEvents":1500,"firstHash":"aaa","lastHash":"bbb","brokenChains":[]}`
+	if string(r.SignedPayload()) != want {
+		t.Errorf("unexpected canonical payload: %s", r.SignedPayload())
+	}
+	// Server-computed signature of want with testSigningKey.
+	mac := hmac.New(sha256.New, []byte(testSigningKey))
+	mac.Write([]byte(want))
+	sig := "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+	r.Signature = &sig
+	if err := r.VerifySignature([]byte(testSigningKey)); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	if err := r.VerifySignature([]byte("other-key")); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for the wrong key, got %v", err)
+	}
+
+	r.TotalEvents = 1501
+	if err := r.VerifySignature([]byte(testSigningKey)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for tampered report, got %v", err)
+	}
+
+	r.Signature = nil
+	if err := r.VerifySignature([]byte(testSigningKey)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for missing signature, got %v", err)
+	}
+}
+
+func TestSignedPayloadSessionReport(t *testing.T) {
+	sid := "s1"
+	r := VerificationReport{
+		Verified:         false,
+		VerifiedAt:       "2024-02-01T00:00:01.000Z",
+		SessionID:        &sid,
+		SessionsVerified: 1,
+		TotalEvents:      3,
+		BrokenChains:     []BrokenChainDetail{{SessionID: "s1", FailedAtIndex: 1, FailedEventID: "e2", Reason: "hash <mismatch>"}},
+	}
+	want := `{"verified":false,"verifiedAt":"2024-02-01T00:00:01.000Z","range":null,"sessionId":"s1","sessionsVerified":1,"totalEvents":3,"firstHash":null,"lastHash":null,"brokenChains":[{"sessionId":"s1","failedAtIndex":1,"failedEventId":"e2","reason":"hash <mismatch>"}]}`
+	if string(r.SignedPayload()) != want {
+		t.Errorf("unexpected canonical payload:\n got %s\nwant %s", r.SignedPayload(), want)
+	}
+}
+
+func TestVerifyAuditWithSigningKey(t *testing.T) {
+	report := testReport()
+	signReport(&report, testSigningKey)
+	tamper := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		out := report
+		if tamper {
+			out.TotalEvents = 1
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithAuditSigningKey(testSigningKey))
+	r, err := c.VerifyAudit(context.Background(), nil)
+	if err != nil || !r.Verified {
+		t.Fatalf("expected verified report, got %v, %v", r.Verified, err)
+	}
+
+	tamper = true
+	r, err = c.VerifyAudit(context.Background(), nil)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+	if r.Verified {
+		t.Error("expected Verified=false for forged report")
+	}
+}
+
+func buildChain(events []Event) []Event {
+	prev := ""
+	for i := range events {
+		if prev != "" {
+			p := prev
+			events[i].PrevHash = &p
+		}
+		h := DefaultEventHash(events[i], prev)
+		events[i].Hash = &h
+		prev = h
+	}
+	return events
+}
+
+func TestRecomputeChain(t *testing.T) {
+	tl := &TimelineResult{Events: buildChain([]Event{
+		{ID: "e1", SessionID: "s1", EventType: "llm_call", Payload: map[string]any{"model": "gpt-4"}},
+		{ID: "e2", SessionID: "s1", EventType: "llm_response"},
+		{ID: "e3", SessionID: "s1", EventType: "custom"},
+	})}
+
+	broken, err := tl.RecomputeChain(nil)
+	if err != nil || len(broken) != 0 {
+		t.Fatalf("expected intact chain, got %v, %v", broken, err)
+	}
+
+	tl.Events[1].Payload = map[string]any{"tampered": true}
+	broken, err = tl.RecomputeChain(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(broken) != 1 || broken[0].FailedEventID != "e2" || broken[0].FailedAtIndex != 1 {
+		t.Errorf("expected break at e2, got %+v", broken)
+	}
+
+	if _, err := (&TimelineResult{Events: []Event{{ID: "e1"}}}).RecomputeChain(nil); !errors.Is(err, ErrNoHashChain) {
+		t.Errorf("expected ErrNoHashChain, got %v", err)
+	}
+}
+
+func TestDefaultEventHashMatchesServer(t *testing.T) {
+	// Expected value computed with the server's computeEventHash (packages/core/src/hash.ts).
+	e := Event{
+		ID:        "e1",
+		Timestamp: "2024-01-01T00:00:00.000Z",
+		SessionID: "s1",
+		AgentID:   "a1",
+		EventType: "custom",
+		Severity:  "info",
+		Payload:   map[string]any{"a": "<b>"},
+	}
+	canonical := `{"v":2,"id":"e1","timestamp":"2024-01-01T00:00:00.000Z","sessionId":"s1","agentId":"a1","eventType":"custom","severity":"info","payload":{"a":"<b>"},"metadata":{},"prevHash":null}`
+	sum := sha256.Sum256([]byte(canonical))
+	if got, want := DefaultEventHash(e, ""), hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("hash mismatch: got %s want %s", got, want)
+	}
+}
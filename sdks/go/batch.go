@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,11 +17,21 @@ import (
 type BatchOption func(*batchConfig)
 
 type batchConfig struct {
-	maxBatchSize  int
-	flushInterval time.Duration
-	maxQueueSize  int
-	bufferDir     string
-	onError       func(error)
+	maxBatchSize   int
+	flushInterval  time.Duration
+	maxQueueSize   int
+	bufferDir      string
+	onError        func(error)
+	validate       bool
+	concurrency    int
+	onFlush        func(sent []Event, err error)
+	maxBufferBytes int64
+	clock          clock
+	baseCtx        context.Context
+	deadLetter     func([]Event, error)
+	flushRPS       float64
+	// dropReportInterval is set by WithDropReportInterval.
+	dropReportInterval time.Duration
 }
 
 func defaultBatchConfig() batchConfig {
@@ -33,6 +44,7 @@ func defaultBatchConfig() batchConfig {
 		flushInterval: 5 * time.Second,
 		maxQueueSize:  10000,
 		bufferDir:     bufDir,
+		clock:         realClock{},
 	}
 }
 
@@ -61,15 +73,145 @@ func WithBatchOnError(fn func(error)) BatchOption {
 	return func(c *batchConfig) { c.onError = fn }
 }
 
+// WithBatchValidation validates each event in Enqueue (see Event.Validate).
+// Invalid events are not queued and are reported via the error callback.
+func WithBatchValidation() BatchOption {
+	return func(c *batchConfig) { c.validate = true }
+}
+
+// WithConcurrency dispatches flushed batches to a pool of n sender goroutines
+// instead of sending on the goroutine that triggered the flush, so a slow
+// server does not block Enqueue. At most n batches are in flight; further
+// flushes wait for a free sender. With n > 1, batches may arrive at the server
+// out of order. The default (0) sends synchronously.
+func WithConcurrency(n int) BatchOption {
+	return func(c *batchConfig) { c.concurrency = n }
+}
+
+// WithOnFlush sets a callback invoked after every send attempt with the batch
+// and the send error (nil on success), e.g. to advance a checkpoint or write
+// a dead-letter record. It runs without internal locks held, so it may call
+// back into the BatchSender.
+func WithOnFlush(fn func(sent []Event, err error)) BatchOption {
+	return func(c *batchConfig) { c.onFlush = fn }
+}
+
+// WithMaxBufferBytes caps the total size of disk buffer files. When a new
+// buffer would exceed the cap, the oldest buffer files are deleted first and
+// each eviction is reported via the error callback. Existing files in the
+// buffer directory count toward the cap. The default (0) is unbounded.
+func WithMaxBufferBytes(n int64) BatchOption {
+	return func(c *batchConfig) { c.maxBufferBytes = n }
+}
+
+// WithDeadLetter sets a callback for batches that fail permanently, with an
+// error that retrying would not fix (see IsRetryable), such as a 400 for a
+// malformed batch. fn receives the batch and the error so the events can be
+// persisted for inspection or re-driven later; such batches are counted in
+// BatchStats.TotalDeadLettered instead of TotalDropped. Quota errors (402)
+// still go to the disk buffer, and transient failures are still dropped.
+// The error callback is called as before. When a *PartialSendError reports
+// that only some events were rejected, fn receives just those.
+func WithDeadLetter(fn func(events []Event, err error)) BatchOption {
+	return func(c *batchConfig) { c.deadLetter = fn }
+}
+
+// WithBaseContext sets the context that background sends derive from: the
+// periodic flush loop, auto-flushes triggered by Enqueue, and WithConcurrency
+// senders. Its values (e.g. a tracing span) reach the send function, and
+// cancelling it stops the flush loop and aborts in-flight background sends.
+// Shutdown cancels the derived context once it returns. The default is
+// context.Background().
+func WithBaseContext(ctx context.Context) BatchOption {
+	return func(c *batchConfig) { c.baseCtx = ctx }
+}
+
+// WithFlushRateLimit spaces batch sends at most rps per second apart, so
+// draining a backlog, e.g. at Shutdown, doesn't send dozens of batches
+// back-to-back. A send waiting for its turn gives up when its context ends;
+// the batch is then dropped and reported via the error callback. The
+// default (0) is unlimited.
+func WithFlushRateLimit(rps float64) BatchOption {
+	return func(c *batchConfig) { c.flushRPS = rps }
+}
+
 // BatchSender queues events and sends them in batches with auto-flush.
 type BatchSender struct {
 	sendFn func(ctx context.Context, events []Event) error
 	cfg    batchConfig
 
-	mu     sync.Mutex
-	queue  []Event
-	stopCh chan struct{}
-	doneCh chan struct{}
+	mu      sync.Mutex
+	queue   []Event
+	closed  bool          // set by Shutdown; later events are discarded
+	spaceCh chan struct{} // closed and replaced when the queue shrinks
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+
+	stopOnce sync.Once
+	workOnce sync.Once
+
+	// ctx is the parent of background sends; cancel is called by Shutdown.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	work     chan []Event
+	workStop chan struct{} // closed by Shutdown to stop the senders
+	workers  sync.WaitGroup
+	pending  sync.WaitGroup // dispatches of batches taken before Shutdown
+
+	limiter *rateLimiter // set by WithFlushRateLimit
+
+	stats batchCounters
+	buf   diskBuffer
+	drops dropReport
+}
+
+// batchCounters holds the monotonic counters behind Stats.
+type batchCounters struct {
+	enqueued  atomic.Int64
+	sent      atomic.Int64
+	dropped   atomic.Int64
+	buffered  atomic.Int64
+	dead      atomic.Int64
+	lastFlush atomic.Int64 // unix nanos
+}
+
+// BatchStats is a snapshot of BatchSender counters. Totals are monotonic
+// over the sender's lifetime.
+type BatchStats struct {
+	// QueueLength is the number of events currently queued.
+	QueueLength int
+	// TotalEnqueued is the number of events accepted by Enqueue.
+	TotalEnqueued int64
+	// TotalSent is the number of events sent successfully.
+	TotalSent int64
+	// TotalDropped is the number of events lost to queue overflow or a failed send.
+	TotalDropped int64
+	// TotalBufferedToDisk is the number of events written to the disk buffer.
+	TotalBufferedToDisk int64
+	// TotalDeadLettered is the number of events passed to WithDeadLetter.
+	TotalDeadLettered int64
+	// LastFlushTime is when the most recent send attempt completed, or zero.
+	LastFlushTime time.Time
+}
+
+// Stats returns a snapshot of the sender's queue length and counters.
+func (b *BatchSender) Stats() BatchStats {
+	b.mu.Lock()
+	queueLen := len(b.queue)
+	b.mu.Unlock()
+	st := BatchStats{
+		QueueLength:         queueLen,
+		TotalEnqueued:       b.stats.enqueued.Load(),
+		TotalSent:           b.stats.sent.Load(),
+		TotalDropped:        b.stats.dropped.Load(),
+		TotalBufferedToDisk: b.stats.buffered.Load(),
+		TotalDeadLettered:   b.stats.dead.Load(),
+	}
+	if ns := b.stats.lastFlush.Load(); ns != 0 {
+		st.LastFlushTime = time.Unix(0, ns)
+	}
+	return st
 }
 
 // NewBatchSender creates a BatchSender with the given send function and options.
@@ -79,83 +221,312 @@ func NewBatchSender(sendFn func(ctx context.Context, events []Event) error, opts
 		o(&cfg)
 	}
 	bs := &BatchSender{
-		sendFn: sendFn,
-		cfg:    cfg,
-		queue:  make([]Event, 0, cfg.maxBatchSize),
-		stopCh: make(chan struct{}),
-		doneCh: make(chan struct{}),
+		sendFn:  sendFn,
+		cfg:     cfg,
+		queue:   make([]Event, 0, cfg.maxBatchSize),
+		spaceCh: make(chan struct{}),
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
 	}
-	go bs.loop()
+	base := cfg.baseCtx
+	if base == nil {
+		base = context.Background()
+	}
+	bs.ctx, bs.cancel = context.WithCancel(base)
+	bs.drops.lastReport = cfg.clock.Now()
+	if cfg.flushRPS > 0 {
+		bs.limiter = newRateLimiter(cfg.flushRPS, 1, cfg.clock)
+	}
+	if cfg.maxBufferBytes > 0 {
+		bs.scanBufferDir()
+	}
+	if cfg.concurrency > 0 {
+		bs.work = make(chan []Event)
+		bs.workStop = make(chan struct{})
+		for i := 0; i < cfg.concurrency; i++ {
+			bs.workers.Add(1)
+			go bs.worker()
+		}
+	}
+	go bs.loop(bs.cfg.clock.NewTicker(bs.cfg.flushInterval))
 	return bs
 }
 
-func (b *BatchSender) loop() {
+func (b *BatchSender) worker() {
+	defer b.workers.Done()
+	for {
+		select {
+		case batch := <-b.work:
+			b.send(b.ctx, batch)
+		case <-b.workStop:
+			return
+		}
+	}
+}
+
+// dispatch sends batch synchronously, or hands it to the worker pool when
+// concurrency is enabled. If ctx ends before a worker is free, the batch is
+// returned to the front of the queue. Once Shutdown has stopped the
+// senders, the batch is discarded with ErrBatchSenderClosed.
+func (b *BatchSender) dispatch(ctx context.Context, batch []Event) error {
+	if b.work == nil {
+		b.send(ctx, batch)
+		return nil
+	}
+	select {
+	case b.work <- batch:
+		return nil
+	case <-b.workStop:
+		b.discard(batch, ErrBatchSenderClosed)
+		return ErrBatchSenderClosed
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.queue = append(batch, b.queue...)
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (b *BatchSender) loop(ticker ticker) {
 	defer close(b.doneCh)
-	ticker := time.NewTicker(b.cfg.flushInterval)
 	defer ticker.Stop()
 	for {
 		select {
-		case <-ticker.C:
-			_ = b.Flush(context.Background())
+		case <-ticker.C():
+			_ = b.Flush(b.ctx)
+			if b.cfg.dropReportInterval > 0 {
+				b.flushDropReport(false)
+			}
 		case <-b.stopCh:
 			return
+		case <-b.ctx.Done():
+			return
 		}
 	}
 }
 
 // Enqueue adds an event to the queue. Thread-safe.
 func (b *BatchSender) Enqueue(event Event) {
+	b.enqueue(event)
+}
+
+// enqueue adds events to the queue as a unit: all or none are queued, and
+// an auto-flush never splits them across batches. Used for event pairs that
+// belong together, such as llm_call/llm_response. After Shutdown has begun
+// the events are discarded with ErrBatchSenderClosed.
+func (b *BatchSender) enqueue(events ...Event) {
+	if !b.tryEnqueue(events...) {
+		b.discard(events, ErrBatchSenderClosed)
+	}
+}
+
+// tryEnqueue is enqueue without the discard: it reports false, leaving the
+// events to the caller, if Shutdown has begun. Events it accepts are sent
+// by Shutdown at the latest.
+func (b *BatchSender) tryEnqueue(events ...Event) bool {
+	if b.cfg.validate {
+		for _, event := range events {
+			if err := event.Validate(); err != nil {
+				if b.cfg.onError != nil {
+					b.cfg.onError(err)
+				}
+				return true
+			}
+		}
+	}
+
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	if b.closed {
+		return false
+	}
 
-	b.queue = append(b.queue, event)
+	b.queue = append(b.queue, events...)
+	b.stats.enqueued.Add(int64(len(events)))
 
 	// Drop oldest on overflow
 	if len(b.queue) > b.cfg.maxQueueSize {
 		drop := len(b.queue) - b.cfg.maxQueueSize
 		b.queue = b.queue[drop:]
-		if b.cfg.onError != nil {
-			b.cfg.onError(fmt.Errorf("queue overflow: dropped %d oldest event(s)", drop))
-		}
+		b.stats.dropped.Add(int64(drop))
+		b.reportOverflow(drop)
 	}
 
 	// Auto-flush at batch size
 	if len(b.queue) >= b.cfg.maxBatchSize {
-		batch := make([]Event, b.cfg.maxBatchSize)
-		copy(batch, b.queue[:b.cfg.maxBatchSize])
-		b.queue = b.queue[b.cfg.maxBatchSize:]
+		n := b.cfg.maxBatchSize
+		if start := len(b.queue) - len(events); n > start && n < len(b.queue) {
+			// The batch would end inside the group: send what precedes it
+			// and leave the group for the next batch, unless nothing does.
+			if start > 0 {
+				n = start
+			} else {
+				n = len(b.queue)
+			}
+		}
+		batch := b.takeBatchLocked(n)
+		b.pending.Add(1)
 		b.mu.Unlock()
-		b.send(context.Background(), batch)
+		_ = b.dispatch(b.ctx, batch)
+		b.pending.Done()
 		b.mu.Lock()
 	}
+	return true
 }
 
-// Flush manually triggers an immediate flush.
-func (b *BatchSender) Flush(ctx context.Context) error {
-	b.mu.Lock()
-	if len(b.queue) == 0 {
+// EnqueueWait adds an event to the queue, blocking while the queue is full
+// instead of dropping the oldest event. It returns ctx.Err() if ctx ends
+// before there is room. Use it for telemetry that must not be lost; Enqueue
+// remains the fire-and-forget variant. Thread-safe.
+func (b *BatchSender) EnqueueWait(ctx context.Context, event Event) error {
+	if b.cfg.validate {
+		if err := event.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			b.discard([]Event{event}, ErrBatchSenderClosed)
+			return ErrBatchSenderClosed
+		}
+		if len(b.queue) < b.cfg.maxQueueSize {
+			b.queue = append(b.queue, event)
+			b.stats.enqueued.Add(1)
+			var batch []Event
+			if len(b.queue) >= b.cfg.maxBatchSize {
+				batch = b.takeBatchLocked(b.cfg.maxBatchSize)
+				b.pending.Add(1)
+			}
+			b.mu.Unlock()
+			if batch != nil {
+				defer b.pending.Done()
+				return b.dispatch(ctx, batch)
+			}
+			return nil
+		}
+		space := b.spaceCh
 		b.mu.Unlock()
-		return nil
+
+		select {
+		case <-space:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
-	n := b.cfg.maxBatchSize
+}
+
+// takeBatchLocked removes up to n events from the front of the queue and
+// wakes any EnqueueWait callers. b.mu must be held.
+func (b *BatchSender) takeBatchLocked(n int) []Event {
 	if n > len(b.queue) {
 		n = len(b.queue)
 	}
 	batch := make([]Event, n)
 	copy(batch, b.queue[:n])
 	b.queue = b.queue[n:]
+	close(b.spaceCh)
+	b.spaceCh = make(chan struct{})
+	return batch
+}
+
+// Flush manually triggers an immediate flush of up to one batch.
+func (b *BatchSender) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	if len(b.queue) == 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	batch := b.takeBatchLocked(b.cfg.maxBatchSize)
+	if !b.closed {
+		b.pending.Add(1)
+		defer b.pending.Done()
+	}
 	b.mu.Unlock()
 
-	b.send(ctx, batch)
-	return nil
+	return b.dispatch(ctx, batch)
 }
 
-// Shutdown stops the background goroutine and drains remaining events.
+// FlushSync sends everything queued, in batches, on the calling goroutine
+// and returns the first send error, e.g. to fail fast on a bad API key by
+// calling it once at startup. Unlike Flush, it bypasses the WithConcurrency
+// senders and reports failures to the caller. A failed batch is still
+// handled like any other (buffered, dead-lettered or dropped, and passed to
+// the error callback); the rest stays queued.
+func (b *BatchSender) FlushSync(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		if len(b.queue) == 0 {
+			b.mu.Unlock()
+			return nil
+		}
+		batch := b.takeBatchLocked(b.cfg.maxBatchSize)
+		b.mu.Unlock()
+
+		if err := b.send(ctx, batch); err != nil {
+			return err
+		}
+	}
+}
+
+// Shutdown stops the background goroutine and drains remaining events,
+// waiting for in-flight batches when concurrency is enabled. It is safe to
+// call more than once, e.g. from a signal handler and a deferred cleanup.
+// Events enqueued once Shutdown has begun are not sent: they are discarded
+// with ErrBatchSenderClosed, which is passed to the dead-letter function
+// and the error callback, and counted in BatchStats.
 func (b *BatchSender) Shutdown(ctx context.Context) error {
-	close(b.stopCh)
+	defer b.cancel()
+	defer b.flushDropReport(true)
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.stopOnce.Do(func() { close(b.stopCh) })
 	<-b.doneCh
+	if err := b.waitPending(ctx); err != nil {
+		return err
+	}
+
+	if err := b.drain(ctx); err != nil {
+		return err
+	}
+	if b.work == nil {
+		return nil
+	}
+	b.workOnce.Do(func() { close(b.workStop) })
+	done := make(chan struct{})
+	go func() {
+		b.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	// Drain remaining
+// waitPending waits, until ctx ends, for batches taken from the queue
+// before Shutdown began to be handed to a sender, so none are discarded.
+func (b *BatchSender) waitPending(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain flushes until the queue is empty or ctx ends.
+func (b *BatchSender) drain(ctx context.Context) error {
 	for {
 		b.mu.Lock()
 		if len(b.queue) == 0 {
@@ -173,45 +544,98 @@ func (b *BatchSender) Shutdown(ctx context.Context) error {
 	}
 }
 
-func (b *BatchSender) send(ctx context.Context, batch []Event) {
+// send sends batch, accounting for and handling any failure, and returns the
+// send function's error.
+func (b *BatchSender) send(ctx context.Context, batch []Event) error {
+	if b.limiter != nil {
+		if err := b.limiter.wait(ctx); err != nil {
+			err = &ConnectionError{
+				APIError: newAPIError(fmt.Sprintf("flush rate limit: %v", err), 0, "CONNECTION_ERROR", nil),
+				Cause:    err,
+			}
+			b.discard(batch, err)
+			return err
+		}
+	}
 	err := b.sendFn(ctx, batch)
+	b.stats.lastFlush.Store(b.cfg.clock.Now().UnixNano())
+	if b.cfg.onFlush != nil {
+		b.cfg.onFlush(batch, err)
+	}
 	if err == nil {
-		return
+		b.stats.sent.Add(int64(len(batch)))
+		return nil
+	}
+
+	// Some events were rejected and the rest stored: only the rejected
+	// ones are lost.
+	var partial *PartialSendError
+	if errors.As(err, &partial) {
+		b.stats.sent.Add(int64(partial.Result.Accepted))
+		rejected := make([]Event, len(partial.Result.Rejected))
+		for i, r := range partial.Result.Rejected {
+			rejected[i] = batch[r.Index]
+		}
+		b.discard(rejected, err)
+		return err
 	}
 
 	// On 402 quota exceeded, buffer to disk
 	var quotaErr *QuotaExceededError
 	if errors.As(err, &quotaErr) {
-		b.bufferToDisk(batch)
-		return
+		if b.bufferToDisk(batch) {
+			b.stats.buffered.Add(int64(len(batch)))
+		} else {
+			b.stats.dropped.Add(int64(len(batch)))
+		}
+		return err
 	}
 
+	b.discard(batch, err)
+	return err
+}
+
+// discard dead-letters or drops events that failed with err and reports err.
+func (b *BatchSender) discard(events []Event, err error) {
+	if b.cfg.deadLetter != nil && !shouldRetry(err) {
+		b.cfg.deadLetter(events, err)
+		b.stats.dead.Add(int64(len(events)))
+	} else {
+		b.stats.dropped.Add(int64(len(events)))
+	}
 	if b.cfg.onError != nil {
 		b.cfg.onError(err)
 	}
 }
 
-func (b *BatchSender) bufferToDisk(events []Event) {
+// bufferToDisk writes events to a buffer file, reporting whether it succeeded.
+func (b *BatchSender) bufferToDisk(events []Event) bool {
 	if err := os.MkdirAll(b.cfg.bufferDir, 0o755); err != nil {
 		if b.cfg.onError != nil {
 			b.cfg.onError(fmt.Errorf("failed to create buffer dir: %w", err))
 		}
-		return
+		return false
 	}
-	filename := fmt.Sprintf("agentlens-buffer-%d-%s.json", time.Now().UnixMilli(), randomSuffix())
+	filename := fmt.Sprintf("agentlens-buffer-%d-%s.json", b.cfg.clock.Now().UnixMilli(), randomSuffix())
 	path := filepath.Join(b.cfg.bufferDir, filename)
 	data, err := json.Marshal(events)
 	if err != nil {
 		if b.cfg.onError != nil {
 			b.cfg.onError(fmt.Errorf("failed to marshal buffer: %w", err))
 		}
-		return
+		return false
+	}
+	if !b.reserveBufferSpace(int64(len(data))) {
+		return false
 	}
 	if err := os.WriteFile(path, data, 0o644); err != nil {
 		if b.cfg.onError != nil {
 			b.cfg.onError(fmt.Errorf("failed to write buffer: %w", err))
 		}
+		return false
 	}
+	b.trackBufferFile(path, int64(len(data)))
+	return true
 }
 
 func randomSuffix() string {
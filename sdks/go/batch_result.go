@@ -0,0 +1,168 @@
+package agentlens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BatchSendResult reports the outcome of sending a batch of events.
+type BatchSendResult struct {
+	// Accepted is the number of events the server stored.
+	Accepted int
+	// Rejected lists the events that were refused, in index order.
+	Rejected []RejectedEvent
+}
+
+// RejectedEvent is an event the server refused.
+type RejectedEvent struct {
+	// Index is the event's position in the slice passed to the send call.
+	Index int
+	// Err is a *ValidationError naming the invalid fields.
+	Err error
+}
+
+// PartialSendError is returned by SendEvents when some events of a batch
+// were rejected and the rest were stored. BatchSender counts the accepted
+// events as sent and dead-letters only the rejected ones.
+type PartialSendError struct {
+	Result *BatchSendResult
+}
+
+func (e *PartialSendError) Error() string {
+	r := e.Result
+	return fmt.Sprintf("agentlens: %d of %d events rejected (first: event %d: %v)",
+		len(r.Rejected), r.Accepted+len(r.Rejected), r.Rejected[0].Index, r.Rejected[0].Err)
+}
+
+// partialSendError converts a send result with rejections to a
+// *PartialSendError.
+func partialSendError(res *BatchSendResult, err error) error {
+	if err == nil && res != nil && len(res.Rejected) > 0 {
+		return &PartialSendError{Result: res}
+	}
+	return err
+}
+
+// SendEventsWithResult is SendEvents reporting per-event results. The
+// server rejects a whole batch if any event in it is invalid; when its
+// validation error names the offending events, those are recorded in
+// Rejected and the remaining events are sent again, so one malformed event
+// no longer loses the batch. err is non-nil only if no events could be
+// sent, or the resend failed.
+func (c *Client) SendEventsWithResult(ctx context.Context, events []Event) (*BatchSendResult, error) {
+	if c.sampleRate == nil && !c.cfg.sessionSampling {
+		return c.sendEventsPartial(ctx, events)
+	}
+	kept := make([]Event, 0, len(events))
+	var pos []int
+	for i, e := range events {
+		if c.keepEvent(e.SessionID, e.AgentID, e.Severity) {
+			kept = append(kept, e)
+			pos = append(pos, i)
+		}
+	}
+	res, err := c.sendEventsPartial(ctx, kept)
+	if res != nil {
+		for i := range res.Rejected {
+			res.Rejected[i].Index = pos[res.Rejected[i].Index]
+		}
+	}
+	return res, err
+}
+
+// sendEventsPartial sends events, dropping and resending around invalid
+// events named by validation errors. Errors record the batch size in their
+// Context. Events are prepared for sending first (see Client.prepare);
+// those the interceptor drops are neither accepted nor rejected. Events
+// over the WithMaxPayloadBytes limit are then truncated or dropped.
+func (c *Client) sendEventsPartial(ctx context.Context, events []Event) (*BatchSendResult, error) {
+	res := &BatchSendResult{}
+	pending := make([]Event, 0, len(events))
+	pos := make([]int, 0, len(events)) // index in events of each pending event
+	for i, e := range events {
+		if !c.prepare(&e) {
+			continue
+		}
+		if l := c.cfg.payloadLimit; l != nil {
+			var err error
+			if e, err = l.fit(e); err != nil {
+				if c.cfg.onError != nil {
+					c.cfg.onError(err)
+				}
+				continue
+			}
+		}
+		pending = append(pending, e)
+		pos = append(pos, i)
+	}
+	for len(pending) > 0 {
+		err := c.sendEventBatch(ctx, pending)
+		if err == nil {
+			res.Accepted = len(pending)
+			break
+		}
+		bad := rejectedEvents(err, len(pending))
+		if len(bad) == 0 || len(bad) == len(pending) {
+			// Nothing can be salvaged; report the events rejected so far too.
+			return res, withErrorContext(err, "batchSize", strconv.Itoa(len(pending)))
+		}
+		next := make([]Event, 0, len(pending)-len(bad))
+		nextPos := make([]int, 0, len(pending)-len(bad))
+		for i, e := range pending {
+			if rejErr, ok := bad[i]; ok {
+				res.Rejected = append(res.Rejected, RejectedEvent{Index: pos[i], Err: rejErr})
+			} else {
+				next = append(next, e)
+				nextPos = append(nextPos, pos[i])
+			}
+		}
+		pending, pos = next, nextPos
+	}
+	sort.Slice(res.Rejected, func(i, j int) bool { return res.Rejected[i].Index < res.Rejected[j].Index })
+	return res, nil
+}
+
+// eventFieldPath matches server validation error paths that name a batch
+// event, e.g. "events.3.sessionId". Client-side validation uses
+// "events[3].sessionId" and deliberately doesn't match: WithClientValidation
+// rejects the whole batch.
+var eventFieldPath = regexp.MustCompile(`^events\.(\d+)\.?(.*)$`)
+
+// rejectedEvents returns a *ValidationError per event index named by err's
+// field errors, or nil if err is not a validation error naming events.
+func rejectedEvents(err error, n int) map[int]error {
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		return nil
+	}
+	fields := ve.FieldErrors()
+	byIndex := map[int][]FieldError{}
+	for path, msg := range fields {
+		m := eventFieldPath.FindStringSubmatch(path)
+		if m == nil {
+			return nil // a batch-level problem, not a bad event
+		}
+		i, _ := strconv.Atoi(m[1])
+		if i >= n {
+			return nil
+		}
+		byIndex[i] = append(byIndex[i], FieldError{Field: m[2], Message: msg})
+	}
+	out := make(map[int]error, len(byIndex))
+	for i, fes := range byIndex {
+		sort.Slice(fes, func(a, b int) bool { return fes[a].Field < fes[b].Field })
+		msgs := make([]string, len(fes))
+		for j, fe := range fes {
+			msgs[j] = fe.Field + ": " + fe.Message
+		}
+		out[i] = &ValidationError{newAPIError(
+			"invalid event: "+strings.Join(msgs, "; "), ve.Status, "VALIDATION_ERROR", fes,
+		)}
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// strictIngestServer rejects a whole batch with the server's validation
+// error shape if any event has an empty agentId, and stores it otherwise.
+func strictIngestServer(t *testing.T) (*httptest.Server, func() []Event) {
+	var mu sync.Mutex
+	var stored []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		var details []map[string]string
+		for i, e := range body.Events {
+			if e.AgentID == "" {
+				details = append(details, map[string]string{
+					"path": fmt.Sprintf("events.%d.agentId", i), "message": "Required",
+				})
+			}
+		}
+		if len(details) > 0 {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(map[string]any{"error": "Validation failed", "details": details})
+			return
+		}
+		mu.Lock()
+		stored = append(stored, body.Events...)
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return stored
+	}
+}
+
+func TestSendEventsPartialSuccess(t *testing.T) {
+	srv, stored := strictIngestServer(t)
+	c := NewClient(srv.URL, "key", WithRetry(RetryConfig{MaxRetries: 0}))
+	events := []Event{
+		{ID: "e0", SessionID: "s1", AgentID: "a1", EventType: "custom"},
+		{ID: "e1", SessionID: "s1", EventType: "custom"},
+		{ID: "e2", SessionID: "s1", AgentID: "a1", EventType: "custom"},
+		{ID: "e3", SessionID: "s1", EventType: "custom"},
+	}
+
+	res, err := c.SendEventsWithResult(context.Background(), events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Accepted != 2 || len(res.Rejected) != 2 || res.Rejected[0].Index != 1 || res.Rejected[1].Index != 3 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	var ve *ValidationError
+	if !errors.As(res.Rejected[0].Err, &ve) || ve.FieldErrors()["agentId"] != "Required" {
+		t.Errorf("unexpected rejection error: %v", res.Rejected[0].Err)
+	}
+	if got := stored(); len(got) != 2 || got[0].ID != "e0" || got[1].ID != "e2" {
+		t.Errorf("expected the valid events to be stored, got %+v", got)
+	}
+
+	var partial *PartialSendError
+	if err := c.SendEvents(context.Background(), events); !errors.As(err, &partial) || partial.Result.Accepted != 2 {
+		t.Errorf("expected a PartialSendError from SendEvents, got %v", err)
+	}
+}
+
+func TestBatchSenderDeadLettersOnlyRejected(t *testing.T) {
+	srv, _ := strictIngestServer(t)
+	c := NewClient(srv.URL, "key", WithRetry(RetryConfig{MaxRetries: 0}))
+	var dead []Event
+	bs := NewBatchSender(c.SendEvents, WithDeadLetter(func(events []Event, err error) {
+		dead = append(dead, events...)
+	}))
+	bs.Enqueue(Event{ID: "ok", SessionID: "s1", AgentID: "a1", EventType: "custom"})
+	bs.Enqueue(Event{ID: "bad", SessionID: "s1", EventType: "custom"})
+	if err := bs.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].ID != "bad" {
+		t.Errorf("expected only the rejected event to be dead-lettered, got %+v", dead)
+	}
+	if st := bs.Stats(); st.TotalSent != 1 || st.TotalDeadLettered != 1 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
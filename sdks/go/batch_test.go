@@ -2,8 +2,10 @@ package agentlens
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -60,6 +62,28 @@ func TestBatchShutdownDrain(t *testing.T) {
 	}
 }
 
+func TestBatchFlushRateLimit(t *testing.T) {
+	var sends atomic.Int32
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		sends.Add(1)
+		return nil
+	}, WithMaxBatchSize(2), WithFlushInterval(time.Hour), WithFlushRateLimit(20))
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		bs.Enqueue(Event{ID: "e"})
+	}
+	bs.Shutdown(context.Background())
+
+	if sends.Load() != 5 {
+		t.Fatalf("expected 5 batches, got %d", sends.Load())
+	}
+	// The first batch goes immediately, the other 4 at 50ms intervals.
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("expected sends spaced to 20/s, took %v", elapsed)
+	}
+}
+
 func TestBatchOverflow(t *testing.T) {
 	var mu sync.Mutex
 	var errMsg string
@@ -88,11 +112,48 @@ func TestBatchOverflow(t *testing.T) {
 	mu.Unlock()
 }
 
+func TestBatchDropReportInterval(t *testing.T) {
+	var mu sync.Mutex
+	var reports []string
+	clk := newFakeClock(time.Unix(1700000000, 0))
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		return nil
+	}, WithMaxBatchSize(100), WithFlushInterval(time.Hour), WithMaxQueueSize(2), withBatchClock(clk),
+		WithDropReportInterval(10*time.Second), WithBatchOnError(func(err error) {
+			mu.Lock()
+			reports = append(reports, err.Error())
+			mu.Unlock()
+		}))
+
+	for i := 0; i < 10; i++ {
+		bs.Enqueue(Event{ID: "e"})
+	}
+	clk.Advance(10 * time.Second)
+	bs.Enqueue(Event{ID: "e"})
+	bs.Enqueue(Event{ID: "e"})
+	bs.Enqueue(Event{ID: "e"})
+	bs.Shutdown(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"queue overflow: dropped 9 oldest event(s) in the last 10s",
+		"queue overflow: dropped 2 oldest event(s) in the last 0s",
+	}
+	if strings.Join(reports, "|") != strings.Join(want, "|") {
+		t.Errorf("reports = %q", reports)
+	}
+	if st := bs.Stats(); st.TotalDropped != 11 {
+		t.Errorf("expected 11 dropped, got %d", st.TotalDropped)
+	}
+}
+
 func TestBatch402DiskBuffer(t *testing.T) {
 	dir := t.TempDir()
+	clk := newFakeClock(time.UnixMilli(1700000000123))
 	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
 		return &QuotaExceededError{newAPIError("quota exceeded", 402, "QUOTA_EXCEEDED", nil)}
-	}, WithMaxBatchSize(2), WithFlushInterval(time.Hour), WithBufferDir(dir))
+	}, WithMaxBatchSize(2), WithFlushInterval(time.Hour), WithBufferDir(dir), withBatchClock(clk))
 
 	bs.Enqueue(Event{ID: "e1"})
 	bs.Enqueue(Event{ID: "e2"})
@@ -102,12 +163,12 @@ func TestBatch402DiskBuffer(t *testing.T) {
 	entries, _ := os.ReadDir(dir)
 	found := false
 	for _, e := range entries {
-		if matched, _ := filepath.Match("agentlens-buffer-*.json", e.Name()); matched {
+		if matched, _ := filepath.Match("agentlens-buffer-1700000000123-*.json", e.Name()); matched {
 			found = true
 		}
 	}
 	if !found {
-		t.Error("expected buffer file on disk")
+		t.Errorf("expected buffer file named from the batch clock, got %v", entries)
 	}
 }
 
@@ -135,3 +196,371 @@ func TestBatchConcurrentEnqueue(t *testing.T) {
 		t.Errorf("expected 100 sent, got %d", sent.Load())
 	}
 }
+
+func TestBatchConcurrency(t *testing.T) {
+	var inFlight, maxInFlight, sent atomic.Int32
+	release := make(chan struct{})
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		n := inFlight.Add(1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-release
+		inFlight.Add(-1)
+		sent.Add(int32(len(events)))
+		return nil
+	}, WithMaxBatchSize(1), WithFlushInterval(time.Hour), WithConcurrency(3))
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			bs.Enqueue(Event{ID: "e"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Enqueue should not block while senders are free")
+	}
+
+	close(release)
+	for i := 0; i < 5; i++ {
+		bs.Enqueue(Event{ID: "e"})
+	}
+	if err := bs.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Load() != 8 {
+		t.Errorf("expected 8 sent, got %d", sent.Load())
+	}
+	if maxInFlight.Load() > 3 {
+		t.Errorf("expected at most 3 in-flight sends, got %d", maxInFlight.Load())
+	}
+}
+
+func TestBatchEnqueueAfterShutdown(t *testing.T) {
+	var sent atomic.Int32
+	var mu sync.Mutex
+	var reported []error
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		sent.Add(int32(len(events)))
+		return nil
+	}, WithMaxBatchSize(1), WithFlushInterval(time.Hour), WithConcurrency(2),
+		WithBatchOnError(func(err error) {
+			mu.Lock()
+			reported = append(reported, err)
+			mu.Unlock()
+		}))
+
+	bs.Enqueue(Event{ID: "e1"})
+	if err := bs.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	bs.Enqueue(Event{ID: "e2"})
+	if err := bs.Flush(context.Background()); err != nil {
+		t.Errorf("Flush after Shutdown = %v", err)
+	}
+	if err := bs.EnqueueWait(context.Background(), Event{ID: "e3"}); !errors.Is(err, ErrBatchSenderClosed) {
+		t.Errorf("EnqueueWait after Shutdown = %v, want ErrBatchSenderClosed", err)
+	}
+
+	if sent.Load() != 1 {
+		t.Errorf("expected only the event enqueued before Shutdown to be sent, got %d", sent.Load())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 2 || !errors.Is(reported[0], ErrBatchSenderClosed) {
+		t.Errorf("expected two ErrBatchSenderClosed reports, got %v", reported)
+	}
+	if st := bs.Stats(); st.TotalDropped != 2 {
+		t.Errorf("expected 2 dropped, got %+v", st)
+	}
+}
+
+func TestBatchStats(t *testing.T) {
+	fail := atomic.Bool{}
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		if fail.Load() {
+			return &QuotaExceededError{newAPIError("quota exceeded", 402, "QUOTA_EXCEEDED", nil)}
+		}
+		return nil
+	}, WithMaxBatchSize(2), WithFlushInterval(time.Hour), WithMaxQueueSize(3), WithBufferDir(t.TempDir()))
+
+	if st := bs.Stats(); !st.LastFlushTime.IsZero() {
+		t.Error("expected zero LastFlushTime before any flush")
+	}
+
+	bs.Enqueue(Event{ID: "e1"})
+	bs.Enqueue(Event{ID: "e2"}) // auto-flush, sent
+	fail.Store(true)
+	bs.Enqueue(Event{ID: "e3"})
+	bs.Enqueue(Event{ID: "e4"}) // auto-flush, buffered to disk
+	bs.Enqueue(Event{ID: "e5"})
+
+	st := bs.Stats()
+	if st.QueueLength != 1 || st.TotalEnqueued != 5 || st.TotalSent != 2 || st.TotalBufferedToDisk != 2 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+	if st.LastFlushTime.IsZero() {
+		t.Error("expected LastFlushTime to be set")
+	}
+	bs.Shutdown(context.Background())
+}
+
+func TestBatchEnqueueWaitBlocksWhenFull(t *testing.T) {
+	var sent atomic.Int32
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		sent.Add(int32(len(events)))
+		return nil
+	}, WithMaxBatchSize(10), WithFlushInterval(time.Hour), WithMaxQueueSize(2))
+	defer bs.Shutdown(context.Background())
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := bs.EnqueueWait(ctx, Event{ID: "e"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Queue is full: a short deadline should expire.
+	shortCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := bs.EnqueueWait(shortCtx, Event{ID: "e"}); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- bs.EnqueueWait(ctx, Event{ID: "e3"}) }()
+	select {
+	case <-done:
+		t.Fatal("EnqueueWait should block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	bs.Flush(ctx)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("EnqueueWait should unblock after a flush")
+	}
+	if st := bs.Stats(); st.QueueLength != 1 || st.TotalDropped != 0 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestBatchOnFlush(t *testing.T) {
+	var mu sync.Mutex
+	var okBatches, failedBatches int
+	var bs *BatchSender
+	fail := atomic.Bool{}
+	bs = NewBatchSender(func(ctx context.Context, events []Event) error {
+		if fail.Load() {
+			return newAPIError("boom", 500, "API_ERROR", nil)
+		}
+		return nil
+	}, WithMaxBatchSize(2), WithFlushInterval(time.Hour), WithOnFlush(func(sent []Event, err error) {
+		mu.Lock()
+		if err == nil {
+			okBatches++
+		} else {
+			failedBatches++
+		}
+		mu.Unlock()
+		bs.Stats() // calling back into the sender must not deadlock
+	}))
+
+	bs.Enqueue(Event{ID: "e1"})
+	bs.Enqueue(Event{ID: "e2"})
+	fail.Store(true)
+	bs.Enqueue(Event{ID: "e3"})
+	bs.Shutdown(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if okBatches != 1 || failedBatches != 1 {
+		t.Errorf("expected 1 ok and 1 failed flush, got %d and %d", okBatches, failedBatches)
+	}
+}
+
+func TestBatchMaxBufferBytesEvictsOldest(t *testing.T) {
+	dir := t.TempDir()
+	// Seed a pre-existing buffer file from a "previous process".
+	stale := filepath.Join(dir, "agentlens-buffer-1-stale.json")
+	if err := os.WriteFile(stale, make([]byte, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var evictions int
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		return &QuotaExceededError{newAPIError("quota exceeded", 402, "QUOTA_EXCEEDED", nil)}
+	}, WithMaxBatchSize(1), WithFlushInterval(time.Hour), WithBufferDir(dir), WithMaxBufferBytes(250),
+		WithBatchOnError(func(err error) {
+			mu.Lock()
+			evictions++
+			mu.Unlock()
+		}))
+
+	for i := 0; i < 6; i++ {
+		bs.Enqueue(Event{ID: "e", SessionID: "s"})
+	}
+	bs.Shutdown(context.Background())
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("expected the oldest (pre-existing) buffer file to be evicted")
+	}
+	entries, _ := filepath.Glob(filepath.Join(dir, "agentlens-buffer-*.json"))
+	var total int64
+	for _, e := range entries {
+		info, _ := os.Stat(e)
+		total += info.Size()
+	}
+	if total > 250 {
+		t.Errorf("buffer dir holds %d bytes, expected at most 250", total)
+	}
+	if len(entries) == 0 {
+		t.Error("expected the newest buffers to be kept")
+	}
+	mu.Lock()
+	if evictions == 0 {
+		t.Error("expected evictions to be reported")
+	}
+	mu.Unlock()
+}
+
+func TestBatchMaxBufferBytesOversizedBatch(t *testing.T) {
+	dir := t.TempDir()
+	var seeded []string
+	for _, name := range []string{"agentlens-buffer-1-a.json", "agentlens-buffer-2-b.json"} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, make([]byte, 50), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		seeded = append(seeded, p)
+	}
+
+	var mu sync.Mutex
+	var reported []error
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		return &QuotaExceededError{newAPIError("quota exceeded", 402, "QUOTA_EXCEEDED", nil)}
+	}, WithMaxBatchSize(1), WithFlushInterval(time.Hour), WithBufferDir(dir), WithMaxBufferBytes(200),
+		WithBatchOnError(func(err error) {
+			mu.Lock()
+			reported = append(reported, err)
+			mu.Unlock()
+		}))
+
+	bs.Enqueue(Event{ID: "e", SessionID: "s", Payload: map[string]any{"blob": strings.Repeat("x", 500)}})
+	bs.Shutdown(context.Background())
+
+	for _, p := range seeded {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("expected %s to survive an oversized batch: %v", filepath.Base(p), err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) == 0 || !strings.Contains(reported[0].Error(), "exceeds buffer cap") {
+		t.Errorf("expected the oversized batch to be reported as dropped, got %v", reported)
+	}
+}
+
+func TestBatchBaseContext(t *testing.T) {
+	type key struct{}
+	base, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "trace-1"))
+	defer cancel()
+	var got atomic.Value
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		got.Store(ctx.Value(key{}))
+		return nil
+	}, WithMaxBatchSize(1), WithFlushInterval(time.Hour), WithBaseContext(base))
+
+	bs.Enqueue(Event{ID: "e1"})
+	if got.Load() != "trace-1" {
+		t.Errorf("expected base context value in send, got %v", got.Load())
+	}
+
+	if err := bs.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if bs.ctx.Err() == nil {
+		t.Error("expected Shutdown to cancel background sends")
+	}
+
+	base2, cancel2 := context.WithCancel(context.Background())
+	bs = NewBatchSender(func(ctx context.Context, events []Event) error { return nil },
+		WithFlushInterval(time.Hour), WithBaseContext(base2))
+	defer bs.Shutdown(context.Background())
+	cancel2()
+	select {
+	case <-bs.doneCh:
+	case <-time.After(time.Second):
+		t.Fatal("flush loop did not stop when the base context was cancelled")
+	}
+}
+
+func TestBatchDeadLetter(t *testing.T) {
+	var status atomic.Int32
+	var dead [][]Event
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		return mapHTTPError(int(status.Load()), "failed", nil, nil)
+	}, WithMaxBatchSize(2), WithFlushInterval(time.Hour), WithBufferDir(t.TempDir()),
+		WithDeadLetter(func(events []Event, err error) {
+			if StatusCode(err) != 400 {
+				t.Errorf("unexpected dead-letter error: %v", err)
+			}
+			dead = append(dead, events)
+		}))
+	defer bs.Shutdown(context.Background())
+
+	status.Store(400)
+	bs.Enqueue(Event{ID: "e1"})
+	bs.Enqueue(Event{ID: "e2"})
+	status.Store(503)
+	bs.Enqueue(Event{ID: "e3"})
+	bs.Enqueue(Event{ID: "e4"})
+	status.Store(402)
+	bs.Enqueue(Event{ID: "e5"})
+	bs.Enqueue(Event{ID: "e6"})
+
+	if len(dead) != 1 || len(dead[0]) != 2 || dead[0][0].ID != "e1" {
+		t.Fatalf("expected only the 400 batch to be dead-lettered, got %v", dead)
+	}
+	st := bs.Stats()
+	if st.TotalDeadLettered != 2 || st.TotalDropped != 2 || st.TotalBufferedToDisk != 2 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestBatchFlushSync(t *testing.T) {
+	var sent atomic.Int32
+	var fail atomic.Bool
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		if fail.Load() {
+			return mapHTTPError(401, "invalid api key", nil, nil)
+		}
+		sent.Add(int32(len(events)))
+		return nil
+	}, WithMaxBatchSize(2), WithFlushInterval(time.Hour), WithConcurrency(2))
+	defer bs.Shutdown(context.Background())
+
+	bs.Enqueue(Event{ID: "e1"})
+	if err := bs.FlushSync(context.Background()); err != nil || sent.Load() != 1 {
+		t.Fatalf("FlushSync = %v, sent %d", err, sent.Load())
+	}
+
+	fail.Store(true)
+	bs.Enqueue(Event{ID: "e2"})
+	var authErr *AuthenticationError
+	if err := bs.FlushSync(context.Background()); !errors.As(err, &authErr) {
+		t.Errorf("expected the send's AuthenticationError, got %v", err)
+	}
+}
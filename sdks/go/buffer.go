@@ -0,0 +1,166 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// bufferFile is a disk buffer file tracked for WithMaxBufferBytes.
+type bufferFile struct {
+	path string
+	size int64
+}
+
+// diskBuffer tracks buffer files, oldest first, and their total size.
+type diskBuffer struct {
+	mu    sync.Mutex
+	files []bufferFile
+	total int64
+}
+
+const bufferFilePattern = "agentlens-buffer-*.json"
+
+// scanBufferDir seeds the tracked files from those already in the buffer
+// directory, so the cap accounts for buffers left by a previous process.
+func (b *BatchSender) scanBufferDir() {
+	files := b.listBufferFiles()
+	b.buf.mu.Lock()
+	defer b.buf.mu.Unlock()
+	for _, f := range files {
+		b.buf.files = append(b.buf.files, f)
+		b.buf.total += f.size
+	}
+}
+
+// listBufferFiles returns the buffer files in the buffer directory, oldest
+// first.
+func (b *BatchSender) listBufferFiles() []bufferFile {
+	paths, err := filepath.Glob(filepath.Join(b.cfg.bufferDir, bufferFilePattern))
+	if err != nil {
+		return nil
+	}
+	type found struct {
+		bufferFile
+		modNano int64
+	}
+	var files []found
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, found{bufferFile{p, info.Size()}, info.ModTime().UnixNano()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].modNano != files[j].modNano {
+			return files[i].modNano < files[j].modNano
+		}
+		return files[i].path < files[j].path
+	})
+	out := make([]bufferFile, len(files))
+	for i, f := range files {
+		out[i] = f.bufferFile
+	}
+	return out
+}
+
+// ReplayBufferedFiles queues the events of the disk buffer files in the
+// buffer directory, oldest file first, deleting each file once all its
+// events are queued. It blocks while the queue is full instead of dropping
+// events. A file that can't be read or parsed is reported via the error
+// callback and left in place. It returns the number of events queued, and
+// ctx.Err() if ctx ends first; the file being replayed is then kept, so
+// some of its events may be sent twice.
+func (b *BatchSender) ReplayBufferedFiles(ctx context.Context) (int, error) {
+	replayed := 0
+	for _, f := range b.listBufferFiles() {
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			b.reportError(fmt.Errorf("failed to read buffer file %s: %w", f.path, err))
+			continue
+		}
+		var events []Event
+		if err := json.Unmarshal(data, &events); err != nil {
+			b.reportError(fmt.Errorf("failed to parse buffer file %s: %w", f.path, err))
+			continue
+		}
+		for _, e := range events {
+			if err := b.EnqueueWait(ctx, e); err != nil {
+				if ctx.Err() != nil {
+					return replayed, ctx.Err()
+				}
+				b.reportError(err)
+				continue
+			}
+			replayed++
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			b.reportError(fmt.Errorf("failed to remove replayed buffer file %s: %w", f.path, err))
+			continue
+		}
+		b.untrackBufferFile(f.path)
+	}
+	return replayed, nil
+}
+
+// reserveBufferSpace evicts the oldest buffer files until n more bytes fit
+// under the cap. It reports false, without evicting anything, if n alone
+// exceeds the cap.
+func (b *BatchSender) reserveBufferSpace(n int64) bool {
+	max := b.cfg.maxBufferBytes
+	if max <= 0 {
+		return true
+	}
+	if n > max {
+		b.reportError(fmt.Errorf("batch of %d bytes exceeds buffer cap of %d bytes: dropped", n, max))
+		return false
+	}
+	b.buf.mu.Lock()
+	defer b.buf.mu.Unlock()
+	for b.buf.total+n > max && len(b.buf.files) > 0 {
+		oldest := b.buf.files[0]
+		b.buf.files = b.buf.files[1:]
+		b.buf.total -= oldest.size
+		if err := os.Remove(oldest.path); err != nil && !os.IsNotExist(err) {
+			b.reportError(fmt.Errorf("failed to evict buffer file %s: %w", oldest.path, err))
+			continue
+		}
+		b.reportError(fmt.Errorf("buffer cap of %d bytes exceeded: evicted %s (%d bytes)", max, oldest.path, oldest.size))
+	}
+	return true
+}
+
+// trackBufferFile records a newly written buffer file.
+func (b *BatchSender) trackBufferFile(path string, size int64) {
+	if b.cfg.maxBufferBytes <= 0 {
+		return
+	}
+	b.buf.mu.Lock()
+	defer b.buf.mu.Unlock()
+	b.buf.files = append(b.buf.files, bufferFile{path, size})
+	b.buf.total += size
+}
+
+// untrackBufferFile forgets a buffer file that was deleted.
+func (b *BatchSender) untrackBufferFile(path string) {
+	b.buf.mu.Lock()
+	defer b.buf.mu.Unlock()
+	for i, f := range b.buf.files {
+		if f.path == path {
+			b.buf.files = append(b.buf.files[:i], b.buf.files[i+1:]...)
+			b.buf.total -= f.size
+			return
+		}
+	}
+}
+
+func (b *BatchSender) reportError(err error) {
+	if b.cfg.onError != nil {
+		b.cfg.onError(err)
+	}
+}
@@ -0,0 +1,61 @@
+package agentlens
+
+import (
+	"container/list"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// responseCache is an LRU of GET response bodies keyed by request path,
+// revalidated with If-None-Match. See WithResponseCache.
+type responseCache struct {
+	mu    sync.Mutex
+	max   int
+	order *list.List // front is most recently used
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key  string
+	etag string
+	body []byte
+}
+
+func newResponseCache(max int) *responseCache {
+	return &responseCache{max: max, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get returns the entry for key, marking it most recently used.
+func (rc *responseCache) get(key string) (*cacheEntry, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	el, ok := rc.items[key]
+	if !ok {
+		return nil, false
+	}
+	rc.order.MoveToFront(el)
+	return el.Value.(*cacheEntry), true
+}
+
+// store records a 2xx response for key if it carries an ETag and may be
+// stored. A no-store response also drops any existing entry.
+func (rc *responseCache) store(key string, h http.Header, body []byte) {
+	etag := h.Get("ETag")
+	noStore := strings.Contains(strings.ToLower(h.Get("Cache-Control")), "no-store")
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if el, ok := rc.items[key]; ok {
+		rc.order.Remove(el)
+		delete(rc.items, key)
+	}
+	if etag == "" || noStore {
+		return
+	}
+	rc.items[key] = rc.order.PushFront(&cacheEntry{key: key, etag: etag, body: body})
+	for rc.order.Len() > rc.max {
+		oldest := rc.order.Back()
+		rc.order.Remove(oldest)
+		delete(rc.items, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,69 @@
+package agentlens
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+)
+
+// baselineFeatures are the features of servers that predate
+// /api/server-info.
+var baselineFeatures = []string{"sessions", "agents", "analytics", "health"}
+
+// Capabilities describes what an AgentLens server supports.
+type Capabilities struct {
+	FailOpenStatus
+	// Version is the server version, e.g. "0.12.1"; empty for baseline
+	// capabilities.
+	Version string `json:"version"`
+	// Features lists the feature keys the server supports, e.g. "recall",
+	// "reflect", "optimize", "guardrails" or "cost-budgets".
+	Features []string `json:"features"`
+	// APIVersion is the current REST API version, e.g. "v1".
+	APIVersion string `json:"apiVersion"`
+	// Baseline is true if the server predates capability discovery and
+	// Features is an assumed minimum set.
+	Baseline bool `json:"-"`
+}
+
+// Has reports whether the server supports feature.
+func (c *Capabilities) Has(feature string) bool {
+	return slices.Contains(c.Features, feature)
+}
+
+// GetCapabilities returns the server's version and supported features from
+// GET /api/server-info and GET /api/version. (GET /api/capabilities is the
+// agent capability registry, not server discovery.) The result is cached
+// for the life of the client. Servers too old to have these endpoints
+// yield a Baseline result instead of a NotFoundError.
+func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	if c.caps != nil {
+		return c.caps, nil
+	}
+
+	var notFound *NotFoundError
+	var result Capabilities
+	err := c.do(ctx, http.MethodGet, "/api/server-info", nil, &result, true)
+	if errors.As(err, &notFound) {
+		result = Capabilities{Features: baselineFeatures, APIVersion: "v1", Baseline: true}
+	} else if err != nil {
+		return &result, c.failOpen(err, &result)
+	} else {
+		var version struct {
+			Current string `json:"current"`
+		}
+		err = c.do(ctx, http.MethodGet, "/api/version", nil, &version, true)
+		if err != nil && !errors.As(err, &notFound) {
+			return &result, c.failOpen(err, &result)
+		}
+		result.APIVersion = version.Current
+		if result.APIVersion == "" {
+			result.APIVersion = "v1"
+		}
+	}
+	c.caps = &result
+	return c.caps, nil
+}
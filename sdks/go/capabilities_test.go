@@ -0,0 +1,61 @@
+package agentlens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetCapabilities(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("%s should not send credentials", r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/api/server-info":
+			w.Write([]byte(`{"version":"0.12.1","features":["sessions","recall","guardrails"],"pricing":{}}`))
+		case "/api/version":
+			w.Write([]byte(`{"current":"v1","supported":["v1"]}`))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key")
+	caps, err := c.GetCapabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.Version != "0.12.1" || caps.APIVersion != "v1" || caps.Baseline {
+		t.Errorf("unexpected capabilities: %+v", caps)
+	}
+	if !caps.Has("recall") || caps.Has("lore") {
+		t.Errorf("unexpected features: %v", caps.Features)
+	}
+	if _, err := c.GetCapabilities(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("capabilities should be cached, got %d requests", requests)
+	}
+}
+
+func TestGetCapabilitiesOldServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		w.Write([]byte(`{"error":"Not found"}`))
+	}))
+	defer srv.Close()
+
+	caps, err := NewClient(srv.URL, "key").GetCapabilities(context.Background())
+	if err != nil {
+		t.Fatalf("a 404 should fall back to baseline capabilities, got %v", err)
+	}
+	if !caps.Baseline || !caps.Has("sessions") || caps.Has("recall") {
+		t.Errorf("unexpected baseline capabilities: %+v", caps)
+	}
+}
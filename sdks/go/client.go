@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"crypto/rand"
@@ -19,10 +23,51 @@ import (
 
 // Client is the AgentLens API client.
 type Client struct {
-	cfg clientConfig
+	*clientState
+	opts *requestOptions // set by WithOptions; nil for the client NewClient returns
+}
+
+// clientState is shared by a Client and the clients derived from it with
+// WithOptions.
+type clientState struct {
+	cfg       clientConfig
+	userAgent string
+	initErr   error // from clientConfig.Validate, returned by every request
+
+	mu      sync.Mutex
+	lastErr error
+
+	keyMu        sync.Mutex
+	cachedKey    string
+	keyExpiresAt time.Time
+
+	cache       *responseCache // nil unless WithResponseCache
+	retryBudget *retryBudget   // nil unless WithRetryBudget
+	rateLimiter *rateLimiter   // nil unless WithRateLimit
+	inFlight    requestSlots
+
+	sampleRate func(agentID string) float64 // nil unless sampling is configured
+	sampledOut atomic.Int64
+	hedged     atomic.Int64
+
+	batchMu sync.Mutex
+	batcher *BatchSender // created on first use; see batchSender
+
+	capsMu sync.Mutex
+	caps   *Capabilities // cached by GetCapabilities
+
+	transport     http.RoundTripper // underlying transport, below any middleware
+	ownsTransport bool              // transport was built by NewClient, not shared
+	closing       chan struct{}     // closed by Close to stop background goroutines
+	closed        bool              // guarded by batchMu
+	closeOnce     sync.Once
+	closeErr      error
 }
 
 // NewClient creates a new Client with the given server URL and API key.
+// NewClient never fails: an invalid configuration (such as a URL without a
+// scheme) is returned as an error from every request. Use NewClientWithError
+// to detect it up front.
 func NewClient(serverURL, apiKey string, opts ...ClientOption) *Client {
 	cfg := defaultConfig()
 	cfg.url = strings.TrimRight(serverURL, "/")
@@ -30,10 +75,48 @@ func NewClient(serverURL, apiKey string, opts ...ClientOption) *Client {
 	for _, o := range opts {
 		o(&cfg)
 	}
-	if cfg.httpClient == nil {
-		cfg.httpClient = &http.Client{Timeout: cfg.timeout}
+	cfg.applyTags()
+	var transport http.RoundTripper
+	var ownsTransport bool
+	cfg.httpClient, transport, ownsTransport = cfg.buildHTTPClient()
+	ua := "agentlens-go/" + Version
+	if cfg.userAgent != "" {
+		ua += " " + cfg.userAgent
+	}
+	c := &Client{clientState: &clientState{cfg: cfg, userAgent: ua, initErr: cfg.Validate(), transport: transport, ownsTransport: ownsTransport, closing: make(chan struct{})}}
+	if cfg.cacheEntries > 0 {
+		c.cache = newResponseCache(cfg.cacheEntries)
+	}
+	switch {
+	case cfg.sampler != nil:
+		c.sampleRate = cfg.sampler
+	case cfg.healthSampling != nil:
+		c.sampleRate = newHealthSampler(c, *cfg.healthSampling).rate
+	}
+	if cfg.retryBudget != nil {
+		c.retryBudget = newRetryBudget(cfg.retryBudget.ratio, cfg.retryBudget.minPerSec, cfg.clock)
 	}
-	return &Client{cfg: cfg}
+	if rl := cfg.rateLimit; rl != nil && rl.rps > 0 {
+		c.rateLimiter = newRateLimiter(rl.rps, rl.burst, cfg.clock)
+	}
+	if cfg.maxInFlight > 0 {
+		c.inFlight.slots = make(chan struct{}, cfg.maxInFlight)
+	}
+	if cfg.batching && cfg.replayOnStart && c.initErr == nil {
+		bs := c.batchSender()
+		go func() { _, _ = bs.ReplayBufferedFiles(bs.ctx) }()
+	}
+	return c
+}
+
+// NewClientWithError is like NewClient but returns an error if the
+// configuration is invalid.
+func NewClientWithError(serverURL, apiKey string, opts ...ClientOption) (*Client, error) {
+	c := NewClient(serverURL, apiKey, opts...)
+	if c.initErr != nil {
+		return nil, c.initErr
+	}
+	return c, nil
 }
 
 // NewClientFromEnv creates a Client from AGENTLENS_SERVER_URL and AGENTLENS_API_KEY environment variables.
@@ -47,26 +130,62 @@ func NewClientFromEnv(opts ...ClientOption) *Client {
 
 // do is the internal HTTP method with retry logic.
 func (c *Client) do(ctx context.Context, method, path string, body any, result any, skipAuth bool) error {
+	if c.initErr != nil {
+		return c.initErr
+	}
+
 	var bodyReader func() (io.Reader, error)
+	var reqData []byte
 	if body != nil {
 		data, err := json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("agentlens: marshal request body: %w", err)
 		}
+		reqData = data
 		bodyReader = func() (io.Reader, error) { return bytes.NewReader(data), nil }
 	}
 
+	if c.cfg.dryRun != nil {
+		var sinkBody any
+		if reqData != nil {
+			sinkBody = json.RawMessage(reqData)
+		}
+		c.cfg.dryRun(method, path, sinkBody)
+		return nil
+	}
+
+	apiKey := c.cfg.apiKey
+	if !skipAuth && c.cfg.apiKeyProvider != nil {
+		key, err := c.resolveAPIKey(ctx)
+		if err != nil {
+			return &AuthenticationError{newAPIError(fmt.Sprintf("api key provider: %v", err), 0, "AUTHENTICATION_ERROR", nil)}
+		}
+		apiKey = key
+	}
+
 	fullURL := c.cfg.url + path
+	retry, httpClient, extraHeaders := c.callSettings()
 	var lastErr error
+	// reauth is set for the one immediate resend after a 401 with a freshly
+	// resolved key; it does not count as a retry.
+	var reauth, reauthed bool
+	if c.retryBudget != nil {
+		c.retryBudget.deposit()
+	}
+	limiter := c.rateLimiter
+	if limiter != nil && path == "/api/health" && c.cfg.rateLimit.skipHealth {
+		limiter = nil
+	}
 
-	for attempt := 0; attempt <= c.cfg.retry.MaxRetries; attempt++ {
-		if attempt > 0 {
+	for attempt := 0; attempt <= retry.MaxRetries; attempt++ {
+		if attempt > 0 && !reauth {
+			if c.retryBudget != nil && !c.retryBudget.withdraw() {
+				return lastErr
+			}
 			// Calculate delay
-			var delay time.Duration
-			if rlErr, ok := lastErr.(*RateLimitError); ok && rlErr.RetryAfter != nil {
-				delay = time.Duration(*rlErr.RetryAfter * float64(time.Second))
-			} else {
-				delay = backoffDelay(c.cfg.retry, attempt-1)
+			delay := backoffDelay(retry, attempt-1)
+			if ra := retryAfterOf(lastErr); ra != nil {
+				delay = time.Duration(*ra * float64(time.Second))
 			}
 			select {
 			case <-ctx.Done():
@@ -78,6 +197,16 @@ func (c *Client) do(ctx context.Context, method, path string, body any, result a
 			}
 		}
 
+		reauth = false
+		if limiter != nil {
+			if err := limiter.wait(ctx); err != nil {
+				return &ConnectionError{
+					APIError: newAPIError(err.Error(), 0, "CONNECTION_ERROR", nil),
+					Cause: err,
+				}
+			}
+		}
+
 		var reqBody io.Reader
 		if bodyReader != nil {
 			var err error
@@ -93,15 +222,53 @@ func (c *Client) do(ctx context.Context, method, path string, body any, result a
 		}
 
 		req.Header.Set("Accept", "application/json")
+		// Set explicitly so responses are compressed even through custom
+		// transports; this turns off net/http's transparent decompression,
+		// so readResponseBody handles it.
+		req.Header.Set("Accept-Encoding", "gzip")
+		req.Header.Set("User-Agent", c.userAgent)
 		if body != nil {
 			req.Header.Set("Content-Type", "application/json")
 		}
-		if !skipAuth && c.cfg.apiKey != "" {
-			req.Header.Set("Authorization", "Bearer "+c.cfg.apiKey)
+		if !skipAuth && apiKey != "" {
+			req.Header.Set(c.cfg.authHeader, c.cfg.authPrefix+apiKey)
+		}
+		if c.cfg.environment != "" {
+			req.Header.Set(environmentHeader, c.cfg.environment)
+		}
+		if c.cfg.tenantID != "" {
+			req.Header.Set(tenantHeader, c.cfg.tenantID)
+		}
+		if c.cfg.appName != "" {
+			req.Header.Set(appNameHeader, c.cfg.appName)
+		}
+		for k, v := range extraHeaders {
+			req.Header.Set(k, v)
+		}
+		var cached *cacheEntry
+		if c.cache != nil && method == http.MethodGet {
+			if e, ok := c.cache.get(path); ok {
+				cached = e
+				req.Header.Set("If-None-Match", e.etag)
+			}
+		}
+		for _, modify := range c.cfg.requestModifiers {
+			if err := modify(req); err != nil {
+				return fmt.Errorf("agentlens: request modifier: %w", err)
+			}
 		}
 
-		resp, err := c.cfg.httpClient.Do(req)
+		if err := c.inFlight.acquire(ctx); err != nil {
+			return &ConnectionError{
+				APIError: newAPIError(err.Error(), 0, "CONNECTION_ERROR", nil),
+				Cause:    err,
+			}
+		}
+		start := time.Now()
+		resp, release, err := c.roundTrip(httpClient, req)
 		if err != nil {
+			c.inFlight.release()
+			c.logAttempt(ctx, method, path, attempt, 0, time.Since(start), reqData, nil, err)
 			lastErr = &ConnectionError{
 				APIError: newAPIError(fmt.Sprintf("request failed: %v", err), 0, "CONNECTION_ERROR", nil),
 				Cause: err,
@@ -109,23 +276,48 @@ func (c *Client) do(ctx context.Context, method, path string, body any, result a
 			if ctx.Err() != nil {
 				return lastErr // context cancelled, don't retry
 			}
+			if !c.retryable(lastErr, attempt) {
+				return lastErr
+			}
 			continue
 		}
 
-		respBody, err := io.ReadAll(resp.Body)
+		respBody, err := readResponseBody(resp, c.cfg.maxResponseBytes)
 		resp.Body.Close()
+		release()
+		c.inFlight.release()
+		if errors.Is(err, ErrResponseTooLarge) {
+			c.logAttempt(ctx, method, path, attempt, resp.StatusCode, time.Since(start), reqData, nil, err)
+			return err
+		}
 		if err != nil {
 			lastErr = &ConnectionError{
 				APIError: newAPIError(fmt.Sprintf("read response: %v", err), 0, "CONNECTION_ERROR", nil),
 				Cause: err,
 			}
+			if !c.retryable(lastErr, attempt) {
+				return lastErr
+			}
 			continue
 		}
+		c.logAttempt(ctx, method, path, attempt, resp.StatusCode, time.Since(start), reqData, respBody, nil)
+		requestID := resp.Header.Get(requestIDHeader)
+		if md := responseMetadataFrom(ctx); md != nil {
+			md.RequestID = requestID
+			md.StatusCode = resp.StatusCode
+		}
+
+		notModified := resp.StatusCode == http.StatusNotModified && cached != nil
+		if notModified {
+			respBody = cached.body
+		} else if c.cache != nil && method == http.MethodGet && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			c.cache.store(path, resp.Header, respBody)
+		}
 
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if notModified || resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			if result != nil && len(respBody) > 0 {
-				if err := json.Unmarshal(respBody, result); err != nil {
-					return fmt.Errorf("agentlens: unmarshal response: %w", err)
+				if err := c.unmarshal(respBody, result); err != nil {
+					return fmt.Errorf("agentlens: unmarshal response from %s %s: %w", method, path, err)
 				}
 			}
 			return nil
@@ -143,9 +335,9 @@ func (c *Client) do(ctx context.Context, method, path string, body any, result a
 			details = errResp.Details
 		}
 
-		// Parse Retry-After for 429
+		// Parse Retry-After for 429 and 503
 		var retryAfter *float64
-		if resp.StatusCode == 429 {
+		if resp.StatusCode == 429 || resp.StatusCode == 503 {
 			if ra := resp.Header.Get("Retry-After"); ra != "" {
 				if v, err := strconv.ParseFloat(ra, 64); err == nil {
 					retryAfter = &v
@@ -154,7 +346,22 @@ func (c *Client) do(ctx context.Context, method, path string, body any, result a
 		}
 
 		apiErr := mapHTTPError(resp.StatusCode, message, details, retryAfter)
-		if shouldRetry(apiErr) {
+		if e, ok := apiErr.(interface{ apiError() *APIError }); ok {
+			e.apiError().RequestID = requestID
+		}
+		var authErr *AuthenticationError
+		if !skipAuth && !reauthed && c.cfg.apiKeyProvider != nil && errors.As(apiErr, &authErr) {
+			// The cached key may have been rotated out; fetch a new one.
+			reauthed = true
+			if key, err := c.reresolveAPIKey(ctx, apiKey); err == nil && key != apiKey {
+				apiKey = key
+				lastErr = apiErr
+				reauth = true
+				attempt--
+				continue
+			}
+		}
+		if c.retryable(apiErr, attempt) {
 			lastErr = apiErr
 			continue
 		}
@@ -163,10 +370,63 @@ func (c *Client) do(ctx context.Context, method, path string, body any, result a
 	return lastErr
 }
 
+// unmarshal decodes a successful response body into result, rejecting
+// unknown fields with WithStrictDecoding.
+func (c *Client) unmarshal(data []byte, result any) error {
+	if !c.cfg.strictDecoding {
+		return json.Unmarshal(data, result)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(result)
+}
+
+// resolveAPIKey returns the API key from the configured provider, calling it
+// at most once per TTL.
+func (c *Client) resolveAPIKey(ctx context.Context) (string, error) {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	if c.cachedKey != "" && c.cfg.clock.Now().Before(c.keyExpiresAt) {
+		return c.cachedKey, nil
+	}
+	key, err := c.cfg.apiKeyProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.cachedKey = key
+	c.keyExpiresAt = c.cfg.clock.Now().Add(c.cfg.apiKeyTTL)
+	return key, nil
+}
+
+// reresolveAPIKey drops rejected from the cache and resolves the key again.
+// A key cached by another call since rejected was sent is kept.
+func (c *Client) reresolveAPIKey(ctx context.Context, rejected string) (string, error) {
+	c.keyMu.Lock()
+	if c.cachedKey == rejected {
+		c.cachedKey = ""
+	}
+	c.keyMu.Unlock()
+	return c.resolveAPIKey(ctx)
+}
+
 // doFailOpen wraps do with fail-open logic.
 func (c *Client) doFailOpen(ctx context.Context, method, path string, body any, result any, skipAuth bool) error {
-	err := c.do(ctx, method, path, body, result, skipAuth)
+	return c.failOpen(c.do(ctx, method, path, body, result, skipAuth), result)
+}
+
+// failOpen reports err and suppresses it when fail-open mode is enabled.
+// With WithFailOpenSentinel, the error is recorded for LastError and result
+// is marked as suppressed if it embeds FailOpenStatus.
+func (c *Client) failOpen(err error, result any) error {
 	if err != nil && c.cfg.failOpen {
+		if c.cfg.failOpenSentinel {
+			c.mu.Lock()
+			c.lastErr = err
+			c.mu.Unlock()
+			if s, ok := result.(interface{ markSuppressed() }); ok {
+				s.markSuppressed()
+			}
+		}
 		if c.cfg.onError != nil {
 			c.cfg.onError(err)
 		}
@@ -175,6 +435,14 @@ func (c *Client) doFailOpen(ctx context.Context, method, path string, body any,
 	return err
 }
 
+// LastError returns the most recent error suppressed by fail-open mode, or nil.
+// Errors are only recorded when the client was created with WithFailOpenSentinel.
+func (c *Client) LastError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastErr
+}
+
 // helper to build query strings
 func addQueryParam(params *url.Values, key string, val *string) {
 	if val != nil {
@@ -194,10 +462,43 @@ func addQueryFloat(params *url.Values, key string, val *float64) {
 	}
 }
 
+func addQueryPrefixed(params *url.Values, prefix string, vals map[string]string) {
+	for k, v := range vals {
+		params.Set(prefix+k, v)
+	}
+}
+
 // ──── Events ────
 
-// QueryEvents queries events with filters and pagination.
+// QueryEvents queries events with filters and pagination. q's
+// PayloadFilters and MetadataFilters are applied to the returned page.
 func (c *Client) QueryEvents(ctx context.Context, q *EventQuery) (*EventQueryResult, error) {
+	var result EventQueryResult
+	err := c.doFailOpen(ctx, http.MethodGet, eventsPath(q), nil, &result, false)
+	result.Events = q.filter(result.Events)
+	return &result, err
+}
+
+// CountEvents returns the number of events matching q's filters. The server
+// has no count endpoint, so this requests a single event and returns the
+// reported total. q's Limit, Offset, Cursor, PayloadFilters and
+// MetadataFilters are ignored.
+func (c *Client) CountEvents(ctx context.Context, q *EventQuery) (int, error) {
+	var cq EventQuery
+	if q != nil {
+		cq = *q
+	}
+	limit, fields := 1, "id"
+	cq.Limit, cq.Offset, cq.Cursor, cq.Fields = &limit, nil, nil, &fields
+	var result EventQueryResult
+	if err := c.do(ctx, http.MethodGet, eventsPath(&cq), nil, &result, false); err != nil {
+		return 0, c.failOpen(err, nil)
+	}
+	return result.Total, nil
+}
+
+// eventsPath builds the GET /api/events path for q.
+func eventsPath(q *EventQuery) string {
 	p := url.Values{}
 	if q != nil {
 		addQueryParam(&p, "sessionId", q.SessionID)
@@ -209,15 +510,51 @@ func (c *Client) QueryEvents(ctx context.Context, q *EventQuery) (*EventQueryRes
 		addQueryParam(&p, "search", q.Search)
 		addQueryInt(&p, "limit", q.Limit)
 		addQueryInt(&p, "offset", q.Offset)
+		addQueryParam(&p, "cursor", q.Cursor)
 		addQueryParam(&p, "order", q.Order)
+		addQueryParam(&p, "fields", q.Fields)
+		addQueryPrefixed(&p, "payload.", q.PayloadFilters)
+		addQueryPrefixed(&p, "metadata.", q.MetadataFilters)
 	}
 	path := "/api/events"
 	if qs := p.Encode(); qs != "" {
 		path += "?" + qs
 	}
-	var result EventQueryResult
-	err := c.doFailOpen(ctx, http.MethodGet, path, nil, &result, false)
-	return &result, err
+	return path
+}
+
+// filter returns the events matching q's PayloadFilters and MetadataFilters,
+// which the server does not apply. events is filtered in place.
+func (q *EventQuery) filter(events []Event) []Event {
+	if q == nil || (len(q.PayloadFilters) == 0 && len(q.MetadataFilters) == 0) {
+		return events
+	}
+	out := events[:0]
+	for _, e := range events {
+		if fieldsMatch(e.Payload, q.PayloadFilters) && fieldsMatch(e.Metadata, q.MetadataFilters) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// fieldsMatch reports whether each key in want is present in m with the
+// same value, comparing non-string values by their fmt.Sprint form.
+func fieldsMatch(m map[string]any, want map[string]string) bool {
+	for k, v := range want {
+		got, ok := m[k]
+		if !ok {
+			return false
+		}
+		if s, isString := got.(string); isString {
+			if s != v {
+				return false
+			}
+		} else if fmt.Sprint(got) != v {
+			return false
+		}
+	}
+	return true
 }
 
 // GetEvent gets a single event by ID.
@@ -227,6 +564,57 @@ func (c *Client) GetEvent(ctx context.Context, id string) (*Event, error) {
 	return &result, err
 }
 
+// getEventsConcurrency bounds the parallel requests made by GetEventsByIDs.
+const getEventsConcurrency = 8
+
+// GetEventsByIDs fetches several events in one call, returning them in the
+// order of ids. The server has no bulk lookup endpoint, so the events are
+// fetched in parallel (at most 8 requests at a time) and duplicate IDs are
+// requested once. An ID that does not exist yields a zero-value Event (empty
+// ID) at its position rather than an error; any other failure aborts the
+// call and is returned. In fail-open mode that failure yields a nil slice.
+func (c *Client) GetEventsByIDs(ctx context.Context, ids []string) ([]Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(map[string]*Event, len(ids))
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	sem := make(chan struct{}, getEventsConcurrency)
+	for _, id := range ids {
+		if _, ok := results[id]; ok {
+			continue
+		}
+		ev := &Event{}
+		results[id] = ev
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			err := c.do(ctx, http.MethodGet, "/api/events/"+url.PathEscape(id), nil, ev, false)
+			var nf *NotFoundError
+			if errors.As(err, &nf) {
+				*ev = Event{}
+			} else if err != nil {
+				// Keep the first failure, not the cancellations it causes.
+				errOnce.Do(func() { firstErr = err; cancel() })
+			}
+		}(id)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, c.failOpen(firstErr, nil)
+	}
+
+	events := make([]Event, len(ids))
+	for i, id := range ids {
+		events[i] = *results[id]
+	}
+	return events, nil
+}
+
 // ──── Sessions ────
 
 // GetSessions queries sessions with filters and pagination.
@@ -237,7 +625,9 @@ func (c *Client) GetSessions(ctx context.Context, q *SessionQuery) (*SessionQuer
 		addQueryParam(&p, "status", q.Status)
 		addQueryParam(&p, "from", q.From)
 		addQueryParam(&p, "to", q.To)
-		addQueryParam(&p, "tags", q.Tags)
+		if len(q.Tags) > 0 {
+			p.Set("tags", strings.Join(q.Tags, ","))
+		}
 		addQueryInt(&p, "limit", q.Limit)
 		addQueryInt(&p, "offset", q.Offset)
 	}
@@ -247,6 +637,9 @@ func (c *Client) GetSessions(ctx context.Context, q *SessionQuery) (*SessionQuer
 	}
 	var result SessionQueryResult
 	err := c.doFailOpen(ctx, http.MethodGet, path, nil, &result, false)
+	if err == nil && q != nil && q.TagMatch == TagMatchAll {
+		result.Sessions = sessionsWithAllTags(result.Sessions, q.Tags)
+	}
 	return &result, err
 }
 
@@ -264,6 +657,197 @@ func (c *Client) GetSessionTimeline(ctx context.Context, id string) (*TimelineRe
 	return &result, err
 }
 
+// defaultTimelinesConcurrency is GetTimelines' worker count when concurrency <= 0.
+const defaultTimelinesConcurrency = 8
+
+// GetTimelines fetches the timelines of several sessions in parallel, with
+// at most concurrency requests in flight (8 if concurrency <= 0). Results and
+// errors are keyed by session ID; a failed session does not stop the others.
+// Cancelling ctx aborts all in-flight requests, and sessions not yet fetched
+// fail with the context's error. In fail-open mode, failures go to the
+// error handler and are left out of both maps.
+func (c *Client) GetTimelines(ctx context.Context, sessionIDs []string, concurrency int) (map[string]*TimelineResult, map[string]error) {
+	if concurrency <= 0 {
+		concurrency = defaultTimelinesConcurrency
+	}
+	results := make(map[string]*TimelineResult, len(sessionIDs))
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	seen := make(map[string]bool, len(sessionIDs))
+	for _, id := range sessionIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			var result TimelineResult
+			var err error
+			select {
+			case sem <- struct{}{}:
+				err = c.do(ctx, http.MethodGet, "/api/sessions/"+url.PathEscape(id)+"/timeline", nil, &result, false)
+				<-sem
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				results[id] = &result
+			} else if err = c.failOpen(err, nil); err != nil {
+				errs[id] = err
+			}
+		}(id)
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// GetSessionSummary gets aggregate cost, token, and error totals for a session.
+// Servers that don't expose /summary (404) are handled by computing the
+// summary from GetSessionTimeline; Computed reports which path was used.
+func (c *Client) GetSessionSummary(ctx context.Context, id string) (*SessionSummary, error) {
+	var result SessionSummary
+	err := c.do(ctx, http.MethodGet, "/api/sessions/"+url.PathEscape(id)+"/summary", nil, &result, false)
+	var nf *NotFoundError
+	if errors.As(err, &nf) {
+		var timeline TimelineResult
+		err = c.do(ctx, http.MethodGet, "/api/sessions/"+url.PathEscape(id)+"/timeline", nil, &timeline, false)
+		if err == nil {
+			result = summarizeTimeline(id, timeline.Events)
+		}
+	}
+	return &result, c.failOpen(err, &result)
+}
+
+// summarizeTimeline computes a SessionSummary from a session's events.
+func summarizeTimeline(sessionID string, events []Event) SessionSummary {
+	s := SessionSummary{SessionID: sessionID, Computed: true}
+	var first, last time.Time
+	for _, e := range events {
+		switch e.EventType {
+		case EventTypeLlmCall:
+			s.CallCount++
+		case EventTypeLlmResponse:
+			if v, ok := e.Payload["costUsd"].(float64); ok {
+				s.TotalCostUsd += v
+			}
+			if usage, ok := e.Payload["usage"].(map[string]any); ok {
+				if v, ok := usage["totalTokens"].(float64); ok {
+					s.TotalTokens += int(v)
+				}
+			}
+		}
+		if isErrorSeverity(e.Severity) {
+			s.ErrorCount++
+		}
+		if ts, err := time.Parse(time.RFC3339Nano, e.Timestamp); err == nil {
+			if first.IsZero() || ts.Before(first) {
+				first = ts
+			}
+			if ts.After(last) {
+				last = ts
+			}
+		}
+	}
+	if !first.IsZero() {
+		s.DurationMs = float64(last.Sub(first)) / float64(time.Millisecond)
+	}
+	return s
+}
+
+// sessionEventsPageSize is the server's maximum page size for /api/events.
+const sessionEventsPageSize = 500
+
+// GetSessionEvents returns every event in a session, paging through
+// /api/events until the server reports no more results. Events are sorted by
+// timestamp, with ties broken by hash-chain position (an event follows the
+// event whose hash is its prevHash). order is "asc" (the default when empty)
+// or "desc". In fail-open mode an error yields a nil slice.
+func (c *Client) GetSessionEvents(ctx context.Context, sessionID, order string) ([]Event, error) {
+	if order == "" {
+		order = "asc"
+	}
+	if order != "asc" && order != "desc" {
+		return nil, fmt.Errorf("agentlens: invalid order %q: must be \"asc\" or \"desc\"", order)
+	}
+	var events []Event
+	for offset := 0; ; {
+		p := url.Values{}
+		p.Set("sessionId", sessionID)
+		p.Set("order", "asc")
+		p.Set("limit", strconv.Itoa(sessionEventsPageSize))
+		p.Set("offset", strconv.Itoa(offset))
+		var page EventQueryResult
+		if err := c.do(ctx, http.MethodGet, "/api/events?"+p.Encode(), nil, &page, false); err != nil {
+			return nil, c.failOpen(err, nil)
+		}
+		events = append(events, page.Events...)
+		offset += len(page.Events)
+		if !page.HasMore || len(page.Events) == 0 {
+			break
+		}
+	}
+	sortSessionEvents(events, order == "desc")
+	return events, nil
+}
+
+// sortSessionEvents orders events by timestamp, then by depth in the hash
+// chain, so events sharing a timestamp keep their causal order.
+func sortSessionEvents(events []Event, desc bool) {
+	byHash := make(map[string]int, len(events))
+	for i, e := range events {
+		if e.Hash != nil {
+			byHash[*e.Hash] = i
+		}
+	}
+	depth := make([]int, len(events))
+	for i := range depth {
+		depth[i] = -1
+	}
+	var chainDepth func(i int, seen int) int
+	chainDepth = func(i int, seen int) int {
+		if depth[i] >= 0 {
+			return depth[i]
+		}
+		d := 0
+		// seen bounds the walk in case of a (corrupt) cyclic chain.
+		if e := events[i]; e.PrevHash != nil && seen < len(events) {
+			if parent, ok := byHash[*e.PrevHash]; ok && parent != i {
+				d = chainDepth(parent, seen+1) + 1
+			}
+		}
+		depth[i] = d
+		return d
+	}
+	type keyed struct {
+		e     Event
+		ts    time.Time
+		depth int
+	}
+	ks := make([]keyed, len(events))
+	for i, e := range events {
+		ts, _ := time.Parse(time.RFC3339Nano, e.Timestamp)
+		ks[i] = keyed{e: e, ts: ts, depth: chainDepth(i, 0)}
+	}
+	sort.SliceStable(ks, func(i, j int) bool {
+		a, b := ks[i], ks[j]
+		if desc {
+			a, b = b, a
+		}
+		if !a.ts.Equal(b.ts) {
+			return a.ts.Before(b.ts)
+		}
+		return a.depth < b.depth
+	})
+	for i, k := range ks {
+		events[i] = k.e
+	}
+}
+
 // ──── Agents ────
 
 // GetAgent gets an agent by ID.
@@ -282,95 +866,225 @@ func generateID() string {
 	return hex.EncodeToString(b)
 }
 
-// LogLlmCall logs a complete LLM call by sending paired events.
+// LogLlmCall logs a complete LLM call by sending paired events. Both events
+// carry the returned callId in their payload, and the request carries it as
+// its Idempotency-Key header, unchanged across retries, so a server that
+// stored the pair before a retried request failed can drop the duplicate.
+// With WithBatching, the pair is queued instead, with no HTTP request on
+// the calling goroutine; both events share one timestamp and are always
+// sent in the same batch. Send errors are then reported through the batch
+// options' error handler.
 func (c *Client) LogLlmCall(ctx context.Context, sessionID, agentID string, params *LogLlmCallParams) (string, error) {
 	callID := generateID()
-	timestamp := time.Now().UTC().Format(time.RFC3339Nano)
+	if !c.keepEvent(sessionID, agentID, SeverityInfo) {
+		return callID, nil
+	}
+	timestamp := c.cfg.clock.Now().UTC().Format(time.RFC3339Nano)
+
+	completion := params.Completion
+	if params.Redact && completion != nil {
+		r := redactedPlaceholder
+		completion = &r
+	}
+
+	llmResponsePayload := map[string]any{
+		"callId":       callID,
+		"provider":     params.Provider,
+		"model":        params.Model,
+		"completion":   completion,
+		"finishReason": params.FinishReason,
+		"usage":        params.Usage,
+		"costUsd":      c.fillCost(params),
+		"latencyMs":    params.LatencyMs,
+	}
+	if params.ToolCalls != nil {
+		llmResponsePayload["toolCalls"] = params.ToolCalls
+	}
+	if params.Redact {
+		llmResponsePayload["redacted"] = true
+	}
+
+	callEvent := sdkEvent(sessionID, agentID, EventTypeLlmCall, SeverityInfo, llmCallPayload(callID, params), timestamp)
+	respEvent := sdkEvent(sessionID, agentID, EventTypeLlmResponse, SeverityInfo, llmResponsePayload, timestamp)
+	respEvent.Metadata = providerMetadata(params)
+	if c.enqueueBatched(callEvent, respEvent) {
+		return callID, nil
+	}
+	err := c.withIdempotencyKey(callID).postEvents(ctx, sessionID, agentID, []Event{callEvent, respEvent}, nil)
+	return callID, err
+}
+
+// postEvents sends events to POST /api/events with fail-open handling,
+// recording sessionID and agentID in the Context of any error. Events are
+// prepared for sending first; if the interceptor drops them all, nothing
+// is sent. With WithClientValidation, the prepared events are validated,
+// so an interceptor's changes are checked too.
+func (c *Client) postEvents(ctx context.Context, sessionID, agentID string, events []Event, result any) error {
+	prepared := make([]Event, 0, len(events))
+	for _, e := range events {
+		if c.prepare(&e) {
+			prepared = append(prepared, e)
+		}
+	}
+	if len(prepared) == 0 {
+		return nil
+	}
+	if c.cfg.validate {
+		if err := validateEvents(prepared); err != nil {
+			return err
+		}
+	}
+	wired := make([]map[string]any, len(prepared))
+	for i, e := range prepared {
+		wired[i] = wire(e)
+	}
+	body := map[string]any{"events": wired}
+	err := c.do(ctx, http.MethodPost, "/api/events", body, result, false)
+	return c.failOpen(withErrorContext(err, "sessionId", sessionID, "agentId", agentID), result)
+}
 
+const redactedPlaceholder = "[REDACTED]"
+
+// providerMetadata returns the llm_response metadata for the params'
+// provider fields, or nil if they are unset.
+func providerMetadata(params *LogLlmCallParams) map[string]any {
+	if params.ProviderRequestID == nil && len(params.ProviderMetadata) == 0 {
+		return nil
+	}
+	md := make(map[string]any, len(params.ProviderMetadata)+1)
+	for k, v := range params.ProviderMetadata {
+		md[k] = v
+	}
+	if params.ProviderRequestID != nil {
+		md["providerRequestId"] = *params.ProviderRequestID
+	}
+	return md
+}
+
+// llmCallPayload builds the llm_call event payload, applying redaction if requested.
+func llmCallPayload(callID string, params *LogLlmCallParams) map[string]any {
 	messages := params.Messages
 	systemPrompt := params.SystemPrompt
-	completion := params.Completion
 	if params.Redact {
 		redacted := make([]LlmMessage, len(params.Messages))
 		for i, m := range params.Messages {
-			redacted[i] = LlmMessage{Role: m.Role, Content: "[REDACTED]"}
+			redacted[i] = LlmMessage{Role: m.Role, Content: redactedPlaceholder}
 		}
 		messages = redacted
 		if systemPrompt != nil {
-			r := "[REDACTED]"
+			r := redactedPlaceholder
 			systemPrompt = &r
 		}
-		if completion != nil {
-			r := "[REDACTED]"
-			completion = &r
-		}
 	}
 
-	llmCallPayload := map[string]any{
+	payload := map[string]any{
 		"callId":   callID,
 		"provider": params.Provider,
 		"model":    params.Model,
 		"messages": messages,
 	}
 	if systemPrompt != nil {
-		llmCallPayload["systemPrompt"] = *systemPrompt
+		payload["systemPrompt"] = *systemPrompt
 	}
 	if params.Parameters != nil {
-		llmCallPayload["parameters"] = params.Parameters
+		payload["parameters"] = params.Parameters
 	}
 	if params.Tools != nil {
-		llmCallPayload["tools"] = params.Tools
+		payload["tools"] = params.Tools
 	}
 	if params.Redact {
-		llmCallPayload["redacted"] = true
+		payload["redacted"] = true
 	}
+	return payload
+}
 
-	llmResponsePayload := map[string]any{
-		"callId":       callID,
-		"provider":     params.Provider,
-		"model":        params.Model,
-		"completion":   completion,
-		"finishReason": params.FinishReason,
-		"usage":        params.Usage,
-		"costUsd":      params.CostUsd,
-		"latencyMs":    params.LatencyMs,
+// prepare readies e for sending: it enriches its metadata and then runs
+// the WithEventInterceptor function, reporting whether e is still to be
+// sent.
+func (c *Client) prepare(e *Event) bool {
+	c.enrichMetadata(e)
+	return c.cfg.interceptor == nil || c.cfg.interceptor(e)
+}
+
+// wire returns the wire form of the prepared e.
+func wire(e Event) map[string]any {
+	if e.Metadata == nil {
+		e.Metadata = map[string]any{}
 	}
-	if params.ToolCalls != nil {
-		llmResponsePayload["toolCalls"] = params.ToolCalls
+	return map[string]any{
+		"sessionId": e.SessionID,
+		"agentId":   e.AgentID,
+		"eventType": e.EventType,
+		"severity":  e.Severity,
+		"payload":   e.Payload,
+		"metadata":  e.Metadata,
+		"timestamp": e.Timestamp,
 	}
-	if params.Redact {
-		llmResponsePayload["redacted"] = true
+}
+
+// sdkEvent builds an SDK-generated event for queueing on a BatchSender.
+func sdkEvent(sessionID, agentID, eventType, severity string, payload map[string]any, timestamp string) Event {
+	return Event{
+		SessionID: sessionID,
+		AgentID:   agentID,
+		EventType: eventType,
+		Severity:  severity,
+		Payload:   payload,
+		Timestamp: timestamp,
 	}
+}
 
-	body := map[string]any{
-		"events": []map[string]any{
-			{
-				"sessionId": sessionID,
-				"agentId":   agentID,
-				"eventType": "llm_call",
-				"severity":  "info",
-				"payload":   llmCallPayload,
-				"metadata":  map[string]any{},
-				"timestamp": timestamp,
-			},
-			{
-				"sessionId": sessionID,
-				"agentId":   agentID,
-				"eventType": "llm_response",
-				"severity":  "info",
-				"payload":   llmResponsePayload,
-				"metadata":  map[string]any{},
-				"timestamp": timestamp,
-			},
-		},
-	}
-
-	err := c.doFailOpen(ctx, http.MethodPost, "/api/events", body, nil, false)
-	return callID, err
+// enrichMetadata merges WithDefaultMetadata and WithMetadataInjector output
+// into e.Metadata. Keys already set on the event win over injected keys,
+// which win over defaults. e.Metadata is replaced, never mutated in place.
+func (c *Client) enrichMetadata(e *Event) {
+	if len(c.cfg.defaultMetadata) == 0 && c.cfg.metadataInjector == nil {
+		return
+	}
+	injected := make(map[string]any, len(c.cfg.defaultMetadata))
+	for k, v := range c.cfg.defaultMetadata {
+		injected[k] = v
+	}
+	if c.cfg.metadataInjector != nil {
+		probe := *e
+		probe.Metadata = injected
+		c.cfg.metadataInjector(&probe)
+		injected = probe.Metadata
+	}
+	if len(injected) == 0 {
+		return
+	}
+	merged := make(map[string]any, len(injected)+len(e.Metadata))
+	for k, v := range injected {
+		merged[k] = v
+	}
+	for k, v := range e.Metadata {
+		merged[k] = v
+	}
+	e.Metadata = merged
 }
 
 // SendEvents sends a batch of events to the server. Useful as the sendFn for BatchSender.
+// With WithClientValidation, events are validated first and an invalid batch
+// is rejected with a *ValidationError naming the offending index and field.
+// If the server rejects only some events, the rest are still stored and a
+// *PartialSendError reports the rejected ones; see SendEventsWithResult.
 func (c *Client) SendEvents(ctx context.Context, events []Event) error {
+	return partialSendError(c.SendEventsWithResult(ctx, events))
+}
+
+// sendEvents is SendEvents without sampling, used by the client's own
+// BatchSender, whose events were sampled when queued.
+func (c *Client) sendEvents(ctx context.Context, events []Event) error {
+	return partialSendError(c.sendEventsPartial(ctx, events))
+}
+
+func (c *Client) sendEventBatch(ctx context.Context, events []Event) error {
+	if c.cfg.validate {
+		if err := validateEvents(events); err != nil {
+			return err
+		}
+	}
 	body := map[string]any{"events": events}
 	return c.do(ctx, http.MethodPost, "/api/events", body, nil, false)
 }
@@ -385,6 +1099,7 @@ func (c *Client) GetLlmAnalytics(ctx context.Context, params *LlmAnalyticsParams
 		addQueryParam(&p, "model", params.Model)
 		addQueryParam(&p, "provider", params.Provider)
 		addQueryParam(&p, "granularity", params.Granularity)
+		addQueryParam(&p, "groupBy", params.GroupBy)
 	}
 	path := "/api/analytics/llm"
 	if qs := p.Encode(); qs != "" {
@@ -397,11 +1112,17 @@ func (c *Client) GetLlmAnalytics(ctx context.Context, params *LlmAnalyticsParams
 
 // ──── Recall / Reflect / Context ────
 
-// Recall performs semantic search.
+// Recall performs semantic search. A MinScore outside [0, 1] is rejected
+// with a *ValidationError before any request is made.
 func (c *Client) Recall(ctx context.Context, q *RecallQuery) (*RecallResult, error) {
+	if q.MinScore != nil && (*q.MinScore < 0 || *q.MinScore > 1) {
+		return &RecallResult{}, newFieldValidationError("minScore", "must be between 0 and 1")
+	}
 	p := url.Values{}
 	p.Set("query", q.Query)
-	addQueryParam(&p, "scope", q.Scope)
+	if q.Scope != "" {
+		p.Set("scope", string(q.Scope))
+	}
 	addQueryParam(&p, "agentId", q.AgentID)
 	addQueryParam(&p, "from", q.From)
 	addQueryParam(&p, "to", q.To)
@@ -409,6 +1130,9 @@ func (c *Client) Recall(ctx context.Context, q *RecallQuery) (*RecallResult, err
 	addQueryFloat(&p, "minScore", q.MinScore)
 	var result RecallResult
 	err := c.doFailOpen(ctx, http.MethodGet, "/api/recall?"+p.Encode(), nil, &result, false)
+	if err == nil {
+		q.refine(&result)
+	}
 	return &result, err
 }
 
@@ -530,13 +1254,26 @@ func (c *Client) GetGuardrail(ctx context.Context, id string) (*GuardrailRule, e
 
 // CreateGuardrail creates a new guardrail rule.
 func (c *Client) CreateGuardrail(ctx context.Context, params *CreateGuardrailParams) (*GuardrailRule, error) {
+	if c.cfg.validate {
+		if err := params.Validate(); err != nil {
+			return &GuardrailRule{}, err
+		}
+	}
 	var result GuardrailRule
 	err := c.doFailOpen(ctx, http.MethodPost, "/api/guardrails", params, &result, false)
 	return &result, err
 }
 
-// UpdateGuardrail updates a guardrail rule.
+// UpdateGuardrail updates a guardrail rule. Only the fields set in params
+// are sent, and the server merges them into the existing rule, so unset
+// fields (e.g. ConditionConfig when only Enabled is set) keep their values.
+// The server has no PATCH route; its PUT already has these semantics.
 func (c *Client) UpdateGuardrail(ctx context.Context, id string, params *UpdateGuardrailParams) (*GuardrailRule, error) {
+	if c.cfg.validate {
+		if err := params.Validate(); err != nil {
+			return &GuardrailRule{}, err
+		}
+	}
 	var result GuardrailRule
 	err := c.doFailOpen(ctx, http.MethodPut, "/api/guardrails/"+url.PathEscape(id), params, &result, false)
 	return &result, err
@@ -585,7 +1322,9 @@ func (c *Client) GetGuardrailStatus(ctx context.Context, id string) (*GuardrailS
 
 // ──── Audit ────
 
-// VerifyAudit verifies audit trail hash chain integrity.
+// VerifyAudit verifies audit trail hash chain integrity. With
+// WithAuditSigningKey, the report signature is also checked; on mismatch
+// Verified is forced to false and ErrInvalidSignature is returned.
 func (c *Client) VerifyAudit(ctx context.Context, params *VerifyAuditParams) (*VerificationReport, error) {
 	p := url.Values{}
 	if params != nil {
@@ -598,6 +1337,11 @@ func (c *Client) VerifyAudit(ctx context.Context, params *VerifyAuditParams) (*V
 		path += "?" + qs
 	}
 	var result VerificationReport
-	err := c.doFailOpen(ctx, http.MethodGet, path, nil, &result, false)
-	return &result, err
+	err := c.do(ctx, http.MethodGet, path, nil, &result, false)
+	if err == nil && c.cfg.auditSigningKey != nil {
+		if err = result.VerifySignature(c.cfg.auditSigningKey); err != nil {
+			result.Verified = false
+		}
+	}
+	return &result, c.failOpen(err, &result)
 }
@@ -0,0 +1,75 @@
+package agentlens
+
+import "context"
+
+// batchSender returns the client's BatchSender, creating it on first use
+// with the WithBatching options. Its send function is SendEvents without
+// sampling, which callers apply before queueing, so metadata enrichment and
+// client validation still apply. It returns nil once
+// the client is closed.
+func (c *Client) batchSender() *BatchSender {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+	if c.closed {
+		return nil
+	}
+	if c.batcher == nil {
+		opts := append([]BatchOption{withBatchClock(c.cfg.clock)}, c.cfg.batchOpts...)
+		// Bind to the shared state only, so request options of the client
+		// that happens to create the sender don't apply to every batch.
+		base := &Client{clientState: c.clientState}
+		c.batcher = NewBatchSender(base.sendEvents, opts...)
+	}
+	return c.batcher
+}
+
+// enqueueBatched queues events, kept together in one batch, for logging
+// methods. It reports false if WithBatching is not set or the client is
+// closed, in which case they send directly. Events logged while Close runs
+// are either accepted before the sender shuts down, and sent by it, or
+// rejected and sent directly.
+func (c *Client) enqueueBatched(events ...Event) bool {
+	if !c.cfg.batching {
+		return false
+	}
+	bs := c.batchSender()
+	return bs != nil && bs.tryEnqueue(events...)
+}
+
+// EnqueueEvent queues e on the client's BatchSender. Without WithBatching
+// the sender is created with default batch options on first use. After
+// Close, EnqueueEvent does nothing.
+func (c *Client) EnqueueEvent(e Event) {
+	if !c.keepEvent(e.SessionID, e.AgentID, e.Severity) {
+		return
+	}
+	if bs := c.batchSender(); bs != nil {
+		bs.tryEnqueue(e)
+	}
+}
+
+// Close shuts the client down: it flushes queued events and stops the
+// client's BatchSender, if one was created, stops background goroutines and
+// closes idle keep-alive connections of a transport the client created for
+// WithProxy, WithTLSConfig or WithConnectionPool. Shared transports, such as
+// http.DefaultTransport or that of a WithHTTPClient client, are left open
+// for their other users. ctx bounds how long to wait for the final sends.
+// Close is safe to call more than once; later calls return the first
+// call's result. The client can still make requests afterwards, but logging
+// methods send directly instead of batching.
+func (c *Client) Close(ctx context.Context) error {
+	c.closeOnce.Do(func() {
+		c.batchMu.Lock()
+		bs := c.batcher
+		c.closed = true
+		c.batchMu.Unlock()
+		close(c.closing)
+		if bs != nil {
+			c.closeErr = bs.Shutdown(ctx)
+		}
+		if ci, ok := c.transport.(interface{ CloseIdleConnections() }); ok && c.ownsTransport {
+			ci.CloseIdleConnections()
+		}
+	})
+	return c.closeErr
+}
@@ -0,0 +1,295 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientBatching(t *testing.T) {
+	var mu sync.Mutex
+	var requests int
+	var received []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		requests++
+		received = append(received, body.Events...)
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithBatching(WithMaxBatchSize(10)), WithDefaultMetadata(map[string]any{"env": "test"}))
+	callID, err := c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{Provider: "openai", Model: "gpt-4"})
+	if err != nil || callID == "" {
+		t.Fatalf("LogLlmCall = %q, %v", callID, err)
+	}
+	c.EnqueueEvent(Event{SessionID: "s1", AgentID: "a1", EventType: "custom", Severity: "info"})
+
+	mu.Lock()
+	if requests != 0 {
+		t.Errorf("expected events to be queued, got %d requests", requests)
+	}
+	mu.Unlock()
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 1 || len(received) != 3 {
+		t.Fatalf("expected 3 events in 1 request, got %d in %d", len(received), requests)
+	}
+	if received[0].EventType != "llm_call" || received[1].EventType != "llm_response" {
+		t.Errorf("unexpected event order: %s, %s", received[0].EventType, received[1].EventType)
+	}
+	if received[0].Payload["callId"] != callID {
+		t.Errorf("expected callId %s, got %v", callID, received[0].Payload["callId"])
+	}
+	if received[2].Metadata["env"] != "test" {
+		t.Errorf("expected default metadata on batched events, got %v", received[2].Metadata)
+	}
+}
+
+func TestClientLogEventDuringClose(t *testing.T) {
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		received.Add(int32(len(body.Events)))
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	var reported atomic.Int32
+	c := NewClient(srv.URL, "key", WithBatching(WithMaxBatchSize(5), WithConcurrency(2),
+		WithBatchOnError(func(err error) { reported.Add(1) })))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				if _, err := c.LogEvent(context.Background(), "s1", "a1", "custom", "", nil); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	// Each event is either queued before the sender shuts down or, after
+	// Close, sent directly; none is dropped.
+	if n := received.Load(); n != 200 || reported.Load() != 0 {
+		t.Errorf("expected all 200 events delivered, got %d (%d errors reported)", n, reported.Load())
+	}
+}
+
+func TestClientEnqueueFromFlushCallbackDuringClose(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	inFlush := make(chan struct{})
+	var once sync.Once
+	var c *Client
+	c = NewClient(srv.URL, "key", WithBatching(WithMaxBatchSize(1), WithOnFlush(func(sent []Event, err error) {
+		once.Do(func() {
+			close(inFlush)
+			time.Sleep(50 * time.Millisecond) // let Close start
+			c.EnqueueEvent(Event{SessionID: "s1", AgentID: "a1", EventType: "custom"})
+		})
+	})))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.LogEvent(context.Background(), "s1", "a1", "custom", "", nil) // auto-flushes synchronously
+	}()
+	<-inFlush
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close(context.Background()) }()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("deadlock: EnqueueEvent from a flush callback blocked while Close ran")
+	}
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return")
+	}
+}
+
+type idleCloser struct {
+	http.RoundTripper
+	closed int
+}
+
+func (t *idleCloser) CloseIdleConnections() { t.closed++ }
+
+func TestClientCloseIdempotent(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	rt := &idleCloser{RoundTripper: http.DefaultTransport}
+	passthrough := func(next http.RoundTripper) http.RoundTripper { return next }
+	c := NewClient(srv.URL, "key", WithBatching(), WithHTTPClient(&http.Client{Transport: rt}), WithMiddleware(passthrough))
+	c.EnqueueEvent(Event{SessionID: "s1", AgentID: "a1", EventType: "custom"})
+	for i := 0; i < 3; i++ {
+		if err := c.Close(context.Background()); err != nil {
+			t.Fatalf("Close #%d: %v", i+1, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected queued event to be flushed once, got %d requests", requests)
+	}
+	if rt.closed != 0 {
+		t.Errorf("expected the caller's transport to be left open, got %d CloseIdleConnections calls", rt.closed)
+	}
+
+	c.EnqueueEvent(Event{SessionID: "s1", AgentID: "a1", EventType: "custom"})
+	if _, err := c.LogEvent(context.Background(), "s1", "a1", "custom", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("expected LogEvent after Close to send directly, got %d requests", requests)
+	}
+}
+
+func TestClientCloseOwnedTransportOnly(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []ClientOption
+		owns bool
+	}{
+		{"default transport", nil, false},
+		{"caller's client", []ClientOption{WithHTTPClient(&http.Client{Transport: &http.Transport{}})}, false},
+		{"connection pool", []ClientOption{WithConnectionPool(10, 2, 0, 0)}, true},
+		{"proxy", []ClientOption{WithProxy("http://proxy.example.com:3128")}, true},
+	} {
+		c := NewClient("http://localhost:3400", "key", tt.opts...)
+		if c.ownsTransport != tt.owns {
+			t.Errorf("%s: ownsTransport = %v, want %v", tt.name, c.ownsTransport, tt.owns)
+		}
+		if tt.owns && c.transport == http.DefaultTransport {
+			t.Errorf("%s: an owned transport must not be http.DefaultTransport", tt.name)
+		}
+		c.Close(context.Background())
+	}
+}
+
+func TestLogLlmCallBatchedPairStaysTogether(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		batches = append(batches, body.Events)
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithBatching(WithMaxBatchSize(3)))
+	c.EnqueueEvent(Event{SessionID: "s1", AgentID: "a1", EventType: "custom"})
+	c.EnqueueEvent(Event{SessionID: "s1", AgentID: "a1", EventType: "custom"})
+	// A plain size-3 batch would split the pair after llm_call.
+	if _, err := c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{Provider: "openai", Model: "gpt-4"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 2 {
+		t.Fatalf("expected batches of 2 and 2, got %v", batches)
+	}
+	call, resp := batches[1][0], batches[1][1]
+	if call.EventType != "llm_call" || resp.EventType != "llm_response" {
+		t.Fatalf("expected llm pair in one batch, got %s, %s", call.EventType, resp.EventType)
+	}
+	if call.Timestamp != resp.Timestamp {
+		t.Errorf("expected paired timestamps, got %s and %s", call.Timestamp, resp.Timestamp)
+	}
+}
+
+func TestClientReplayOnStart(t *testing.T) {
+	var mu sync.Mutex
+	var received []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		received = append(received, body.Events...)
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	buffered := []Event{
+		{SessionID: "s1", AgentID: "a1", EventType: "custom", Severity: "info"},
+		{SessionID: "s1", AgentID: "a1", EventType: "custom", Severity: "warn"},
+	}
+	data, _ := json.Marshal(buffered)
+	path := filepath.Join(dir, "agentlens-buffer-1-abcdef.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(srv.URL, "key", WithReplayOnStart(),
+		WithBatching(WithBufferDir(dir), WithMaxBatchSize(10), WithFlushInterval(time.Hour)))
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("buffer file was not replayed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 || received[1].Severity != "warn" {
+		t.Errorf("expected the 2 buffered events to be sent, got %+v", received)
+	}
+}
@@ -1,11 +1,15 @@
 package agentlens
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -45,6 +49,21 @@ func TestNewClientTrailingSlash(t *testing.T) {
 	}
 }
 
+func TestNewClientInvalidURL(t *testing.T) {
+	for _, u := range []string{"", "localhost:3400", "ftp://host", "http://", "http://bad host"} {
+		if _, err := NewClientWithError(u, "key"); err == nil {
+			t.Errorf("NewClientWithError(%q): expected error", u)
+		}
+		c := NewClient(u, "key")
+		if _, err := c.QueryEvents(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "invalid configuration") {
+			t.Errorf("NewClient(%q): expected configuration error on first request, got %v", u, err)
+		}
+	}
+	if _, err := NewClientWithError("https://agentlens.example.com", "key"); err != nil {
+		t.Errorf("unexpected error for valid URL: %v", err)
+	}
+}
+
 func TestAuthHeader(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		auth := r.Header.Get("Authorization")
@@ -63,6 +82,152 @@ func TestAuthHeader(t *testing.T) {
 	}
 }
 
+func TestUserAgent(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	NewClient(srv.URL, "key").Health(context.Background())
+	NewClient(srv.URL, "key", WithUserAgent("my-agent/1.2")).Health(context.Background())
+
+	if got[0] != "agentlens-go/"+Version {
+		t.Errorf("unexpected default User-Agent: %q", got[0])
+	}
+	if got[1] != "agentlens-go/"+Version+" my-agent/1.2" {
+		t.Errorf("unexpected custom User-Agent: %q", got[1])
+	}
+}
+
+func TestCustomAuthHeader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-API-Key"); got != "my-key" {
+			t.Errorf("expected X-API-Key my-key, got %q", got)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Authorization should be absent, got %s", auth)
+		}
+		w.Write([]byte(`{"events":[],"total":0,"hasMore":false}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "my-key", WithAuthHeader("X-API-Key", ""))
+	if _, err := c.QueryEvents(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPIKeyProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer rotated-key" {
+			t.Errorf("expected Bearer rotated-key, got %s", auth)
+		}
+		w.Write([]byte(`{"events":[],"total":0,"hasMore":false}`))
+	}))
+	defer srv.Close()
+
+	var calls int
+	c := NewClient(srv.URL, "static-key", WithAPIKeyProvider(func(ctx context.Context) (string, error) {
+		calls++
+		return "rotated-key", nil
+	}))
+	for i := 0; i < 3; i++ {
+		if _, err := c.QueryEvents(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected provider to be cached, got %d calls", calls)
+	}
+}
+
+func TestAPIKeyProviderCacheTTL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"events":[],"total":0,"hasMore":false}`))
+	}))
+	defer srv.Close()
+
+	clk := newFakeClock(time.Unix(1700000000, 0))
+	var calls int
+	c := NewClient(srv.URL, "", withClock(clk), WithAPIKeyCacheTTL(time.Minute), WithAPIKeyProvider(func(ctx context.Context) (string, error) {
+		calls++
+		return "key", nil
+	}))
+	c.QueryEvents(context.Background(), nil)
+	clk.Advance(59 * time.Second)
+	c.QueryEvents(context.Background(), nil)
+	if calls != 1 {
+		t.Errorf("expected cached key within the TTL, got %d provider calls", calls)
+	}
+	clk.Advance(time.Second)
+	c.QueryEvents(context.Background(), nil)
+	if calls != 2 {
+		t.Errorf("expected the key to be resolved again after the TTL, got %d provider calls", calls)
+	}
+}
+
+func TestAPIKeyProviderReresolvesOn401(t *testing.T) {
+	var auths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		auths = append(auths, auth)
+		if auth != "Bearer new-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid api key"}`))
+			return
+		}
+		w.Write([]byte(`{"events":[],"total":0,"hasMore":false}`))
+	}))
+	defer srv.Close()
+
+	keys := []string{"old-key", "new-key"}
+	c := NewClient(srv.URL, "", WithRetry(RetryConfig{}), WithAPIKeyProvider(func(ctx context.Context) (string, error) {
+		key := keys[0]
+		if len(keys) > 1 {
+			keys = keys[1:]
+		}
+		return key, nil
+	}))
+	if _, err := c.QueryEvents(context.Background(), nil); err != nil {
+		t.Fatalf("expected the request to succeed with the re-resolved key: %v", err)
+	}
+	if len(auths) != 2 || auths[0] != "Bearer old-key" || auths[1] != "Bearer new-key" {
+		t.Errorf("expected one resend with the new key, got %q", auths)
+	}
+
+	// A key the server still rejects after re-resolving is not retried again.
+	keys = []string{"bad-key"}
+	c = NewClient(srv.URL, "", WithRetry(RetryConfig{}), WithAPIKeyProvider(func(ctx context.Context) (string, error) {
+		return keys[0], nil
+	}))
+	auths = nil
+	var authErr *AuthenticationError
+	if _, err := c.QueryEvents(context.Background(), nil); !errors.As(err, &authErr) {
+		t.Fatalf("expected AuthenticationError, got %T: %v", err, err)
+	}
+	if len(auths) != 1 {
+		t.Errorf("expected no resend when the provider returns the same key, got %d requests", len(auths))
+	}
+}
+
+func TestAPIKeyProviderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not be sent when the key provider fails")
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "", WithAPIKeyProvider(func(ctx context.Context) (string, error) {
+		return "", errors.New("vault unavailable")
+	}))
+	_, err := c.QueryEvents(context.Background(), nil)
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected AuthenticationError, got %T: %v", err, err)
+	}
+}
+
 func TestHealthSkipsAuth(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if auth := r.Header.Get("Authorization"); auth != "" {
@@ -122,6 +287,49 @@ func TestGetEvent(t *testing.T) {
 	}
 }
 
+func TestGetEventsByIDs(t *testing.T) {
+	var mu sync.Mutex
+	requested := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/api/events/")
+		mu.Lock()
+		requested[id]++
+		mu.Unlock()
+		switch id {
+		case "missing":
+			w.WriteHeader(404)
+			w.Write([]byte(`{"error":"Event not found"}`))
+		case "broken":
+			w.WriteHeader(400)
+			w.Write([]byte(`{"error":"bad id"}`))
+		default:
+			json.NewEncoder(w).Encode(Event{ID: id})
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	events, err := c.GetEventsByIDs(context.Background(), []string{"e2", "missing", "e1", "e2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	if strings.Join(ids, ",") != "e2,,e1,e2" {
+		t.Errorf("expected input order with a placeholder for the miss, got %q", ids)
+	}
+	if requested["e2"] != 1 {
+		t.Errorf("expected duplicate IDs to be fetched once, got %d", requested["e2"])
+	}
+
+	var ve *ValidationError
+	if _, err := c.GetEventsByIDs(context.Background(), []string{"e1", "broken"}); !errors.As(err, &ve) {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+}
+
 func TestGetSessions(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(SessionQueryResult{Sessions: []Session{{ID: "s1"}}, Total: 1})
@@ -167,6 +375,129 @@ func TestGetSessionTimeline(t *testing.T) {
 	}
 }
 
+func TestGetSessionEvents(t *testing.T) {
+	h := func(s string) *string { return &s }
+	// Two pages; e2 and e3 share a timestamp and arrive out of chain order.
+	pages := [][]Event{
+		{
+			{ID: "e3", Timestamp: "2024-01-01T00:00:01Z", Hash: h("h3"), PrevHash: h("h2")},
+			{ID: "e1", Timestamp: "2024-01-01T00:00:00Z", Hash: h("h1")},
+		},
+		{
+			{ID: "e2", Timestamp: "2024-01-01T00:00:01Z", Hash: h("h2"), PrevHash: h("h1")},
+		},
+	}
+	var offsets []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sessionId") != "s1" {
+			t.Errorf("unexpected sessionId: %s", r.URL.Query().Get("sessionId"))
+		}
+		offsets = append(offsets, r.URL.Query().Get("offset"))
+		page := len(offsets) - 1
+		json.NewEncoder(w).Encode(EventQueryResult{Events: pages[page], Total: 3, HasMore: page == 0})
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	events, err := c.GetSessionEvents(context.Background(), "s1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(offsets, ",") != "0,2" {
+		t.Errorf("unexpected page offsets: %v", offsets)
+	}
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	if strings.Join(ids, ",") != "e1,e2,e3" {
+		t.Errorf("expected e1,e2,e3, got %v", ids)
+	}
+
+	offsets = nil
+	events, err = c.GetSessionEvents(context.Background(), "s1", "desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events[0].ID != "e3" || events[2].ID != "e1" {
+		t.Errorf("expected descending order, got %v", events)
+	}
+
+	if _, err := c.GetSessionEvents(context.Background(), "s1", "random"); err == nil {
+		t.Error("expected error for invalid order")
+	}
+}
+
+func TestRequestIDOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-123")
+		w.WriteHeader(400)
+		w.Write([]byte(`{"error":"bad sessionId"}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	var md ResponseMetadata
+	_, err := c.GetEvent(WithResponseMetadata(context.Background(), &md), "e1")
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError, got %T", err)
+	}
+	if ve.RequestID != "req-123" {
+		t.Errorf("expected request ID req-123, got %q", ve.RequestID)
+	}
+	if !strings.Contains(err.Error(), "request_id=req-123") {
+		t.Errorf("expected request ID in message: %s", err)
+	}
+	if md.RequestID != "req-123" || md.StatusCode != 400 {
+		t.Errorf("unexpected response metadata: %+v", md)
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	var full, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/agents/nostore" {
+			w.Header().Set("ETag", `"n1"`)
+			w.Header().Set("Cache-Control", "no-store")
+			if r.Header.Get("If-None-Match") != "" {
+				t.Error("no-store response must not be revalidated")
+			}
+			json.NewEncoder(w).Encode(Agent{ID: "nostore"})
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"id":"a1","name":"cached"}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key", WithResponseCache(10))
+
+	for i := 0; i < 3; i++ {
+		agent, err := c.GetAgent(context.Background(), "a1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if agent.ID != "a1" || agent.Name == nil || *agent.Name != "cached" {
+			t.Errorf("call %d: unexpected agent %+v", i, agent)
+		}
+	}
+	if full != 1 || notModified != 2 {
+		t.Errorf("expected 1 full response and 2 revalidations, got %d and %d", full, notModified)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetAgent(context.Background(), "nostore"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestGetAgent(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(Agent{ID: "a1"})
@@ -218,6 +549,207 @@ func TestLogLlmCall(t *testing.T) {
 	}
 }
 
+func TestLogLlmCallProviderMetadata(t *testing.T) {
+	var received []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []map[string]any `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body.Events...)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key")
+	reqID := "req_abc123"
+	comp := "secret answer"
+	_, err := c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{
+		Provider:          "openai",
+		Model:             "gpt-4",
+		Completion:        &comp,
+		Redact:            true,
+		ProviderRequestID: &reqID,
+		ProviderMetadata:  map[string]any{"systemFingerprint": "fp_1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(received))
+	}
+	if md := received[0]["metadata"].(map[string]any); len(md) != 0 {
+		t.Errorf("llm_call metadata should be empty, got %v", md)
+	}
+	resp := received[1]
+	md := resp["metadata"].(map[string]any)
+	if md["providerRequestId"] != reqID || md["systemFingerprint"] != "fp_1" {
+		t.Errorf("unexpected llm_response metadata: %v", md)
+	}
+	if got := resp["payload"].(map[string]any)["completion"]; got != "[REDACTED]" {
+		t.Errorf("completion should still be redacted, got %v", got)
+	}
+}
+
+func TestLogErrorsCarryContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+		w.Write([]byte(`{"error":"boom"}`))
+	}))
+	defer srv.Close()
+
+	var captured error
+	c := NewClient(srv.URL, "key",
+		WithRetry(RetryConfig{MaxRetries: 0}),
+		WithFailOpen(func(err error) { captured = err }))
+	if _, err := c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{Provider: "openai", Model: "gpt-4"}); err != nil {
+		t.Fatalf("fail-open should suppress the error, got %v", err)
+	}
+	var apiErr *ServerError
+	if !errors.As(captured, &apiErr) {
+		t.Fatalf("expected a ServerError, got %v", captured)
+	}
+	if apiErr.Context["sessionId"] != "s1" || apiErr.Context["agentId"] != "a1" {
+		t.Errorf("unexpected error context: %v", apiErr.Context)
+	}
+
+	err := NewClient(srv.URL, "key", WithRetry(RetryConfig{MaxRetries: 0})).
+		SendEvents(context.Background(), []Event{{SessionID: "s1", EventType: "custom"}, {SessionID: "s1", EventType: "custom"}})
+	if !errors.As(err, &apiErr) || apiErr.Context["batchSize"] != "2" {
+		t.Errorf("expected batchSize=2 in the error context, got %v", err)
+	}
+}
+
+func TestMetadataEnrichment(t *testing.T) {
+	var received []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []map[string]any `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body.Events...)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key",
+		WithDefaultMetadata(map[string]any{"region": "us", "gitSha": "abc"}),
+		WithMetadataInjector(func(e *Event) {
+			e.Metadata["region"] = "eu"
+			e.Metadata["eventType"] = e.EventType
+		}))
+
+	explicit := map[string]any{"gitSha": "explicit"}
+	if err := c.SendEvents(context.Background(), []Event{{ID: "e1", EventType: "custom", Metadata: explicit}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(explicit) != 1 {
+		t.Error("the caller's metadata map must not be modified")
+	}
+	if _, err := c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{Provider: "openai", Model: "gpt-4"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(received) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(received))
+	}
+	md := received[0]["metadata"].(map[string]any)
+	if md["gitSha"] != "explicit" || md["region"] != "eu" || md["eventType"] != "custom" {
+		t.Errorf("unexpected merged metadata: %v", md)
+	}
+	md = received[2]["metadata"].(map[string]any)
+	if md["gitSha"] != "abc" || md["region"] != "eu" || md["eventType"] != "llm_response" {
+		t.Errorf("unexpected LLM event metadata: %v", md)
+	}
+}
+
+func TestEventInterceptor(t *testing.T) {
+	var requests int
+	var received []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []map[string]any `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests++
+		received = append(received, body.Events...)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	interceptor := WithEventInterceptor(func(e *Event) bool {
+		if e.EventType == EventTypeToolError {
+			return false
+		}
+		// Runs after metadata enrichment.
+		e.Metadata = map[string]any{"env": e.Metadata["env"], "intercepted": true}
+		return true
+	})
+	ctx := context.Background()
+
+	c := NewClient(srv.URL, "key", interceptor, WithDefaultMetadata(map[string]any{"env": "test"}))
+	if _, err := c.LogToolCall(ctx, "s1", "a1", &LogToolCallParams{ToolName: "search", Error: "timeout"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendEvents(ctx, []Event{{SessionID: "s1", EventType: EventTypeToolError}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.LogEvent(ctx, "s1", "a1", EventTypeToolError, SeverityError, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	batched := NewClient(srv.URL, "key", interceptor, WithBatching(WithMaxBatchSize(10)))
+	batched.EnqueueEvent(Event{SessionID: "s1", EventType: EventTypeToolError})
+	batched.EnqueueEvent(Event{SessionID: "s1", EventType: EventTypeCustom})
+	if err := batched.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// One request each for LogToolCall's tool_call and the batch; the
+	// calls with only vetoed events send nothing.
+	if requests != 2 || len(received) != 2 {
+		t.Fatalf("expected 2 requests with 2 events, got %d requests: %v", requests, received)
+	}
+	if received[0]["eventType"] != EventTypeToolCall || received[1]["eventType"] != EventTypeCustom {
+		t.Errorf("unexpected events sent: %v", received)
+	}
+	md := received[0]["metadata"].(map[string]any)
+	if md["env"] != "test" || md["intercepted"] != true {
+		t.Errorf("unexpected metadata: %v", md)
+	}
+}
+
+func TestEventInterceptorValidated(t *testing.T) {
+	var received []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []map[string]any `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body.Events...)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key", WithClientValidation(), WithEventInterceptor(func(e *Event) bool {
+		if e.Payload["rename"] != nil {
+			e.EventType = e.Payload["rename"].(string)
+		}
+		return true
+	}))
+	ctx := context.Background()
+
+	// The event is valid as logged but not once the interceptor changes it.
+	_, err := c.LogEvent(ctx, "s1", "a1", EventTypeCustom, "", map[string]any{"rename": ""})
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || vErr.FieldErrors()["events[0].eventType"] == "" {
+		t.Fatalf("expected a ValidationError for the intercepted eventType, got %v", err)
+	}
+	if _, err := c.LogEvent(ctx, "s1", "a1", EventTypeCustom, "", map[string]any{"rename": "renamed"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || received[0]["eventType"] != "renamed" {
+		t.Errorf("expected only the valid intercepted event to be sent, got %v", received)
+	}
+}
+
 func TestGetLlmAnalytics(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(LlmAnalyticsResult{
@@ -335,7 +867,7 @@ func TestRecall(t *testing.T) {
 		if r.URL.Query().Get("query") != "test query" {
 			t.Errorf("unexpected query param: %s", r.URL.Query().Get("query"))
 		}
-		json.NewEncoder(w).Encode(RecallResult{Results: []any{"result1"}})
+		w.Write([]byte(`{"results":[{"sourceType":"event","sourceId":"e1","score":0.91,"text":"timeout calling search","metadata":{"embeddingModel":"m"},"futureField":true}],"query":"test query","totalResults":1}`))
 	}))
 	defer srv.Close()
 	c := NewClient(srv.URL, "key")
@@ -343,7 +875,434 @@ func TestRecall(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(r.Results) != 1 {
-		t.Errorf("expected 1 result, got %d", len(r.Results))
+	if len(r.Results) != 1 || r.TotalResults != 1 {
+		t.Fatalf("expected 1 result, got %d", len(r.Results))
+	}
+	m := r.Results[0]
+	if m.EventID() != "e1" || m.Score != 0.91 || m.Text != "timeout calling search" {
+		t.Errorf("unexpected match: %+v", m)
+	}
+	if !strings.Contains(string(m.Raw), "futureField") {
+		t.Errorf("expected unknown fields to be kept in Raw, got %s", m.Raw)
+	}
+}
+
+func TestReflect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"analysis":"error_patterns","insights":[{"type":"error_pattern","summary":"timeouts","data":{"count":3},"confidence":0.8}],"metadata":{"sessionsAnalyzed":2,"eventsAnalyzed":40,"timeRange":{"from":"a","to":"b"}}}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+	r, err := c.Reflect(context.Background(), &ReflectQuery{Analysis: "error_patterns"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Analysis != "error_patterns" || len(r.Insights) != 1 || r.Insights[0].Data["count"] != 3.0 || r.Metadata.EventsAnalyzed != 40 {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	if r.Metadata.TimeRange.To != "b" || len(r.Raw) == 0 {
+		t.Errorf("unexpected metadata/raw: %+v", r.Metadata)
+	}
+}
+
+func TestGetContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"topic":"billing","sessions":[{"sessionId":"s1","agentId":"a1","startedAt":"t","relevanceScore":0.7,"keyEvents":[{"id":"e1","eventType":"tool_call","summary":"lookup","timestamp":"t"}]}],"lessons":[{"id":"l1","title":"Retry","content":"c","category":"ops","importance":"high","relevanceScore":0.5}],"totalSessions":1}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+	r, err := c.GetContext(context.Background(), &ContextQuery{Topic: "billing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Topic != "billing" || len(r.Sessions) != 1 || r.Sessions[0].KeyEvents[0].ID != "e1" || r.Lessons[0].Importance != "high" {
+		t.Errorf("unexpected result: %+v", r)
+	}
+}
+
+func TestGetSessionSummary(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/sessions/s1/summary" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(SessionSummary{SessionID: "s1", TotalCostUsd: 1.5, CallCount: 3})
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+	s, err := c.GetSessionSummary(context.Background(), "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Computed || s.CallCount != 3 || s.TotalCostUsd != 1.5 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+}
+
+func TestGetSessionSummaryFallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/sessions/s1/summary" {
+			w.WriteHeader(404)
+			w.Write([]byte(`{"error":"not found"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(TimelineResult{Events: []Event{
+			{EventType: "llm_call", Severity: "info", Timestamp: "2024-01-01T00:00:00Z"},
+			{EventType: "llm_response", Severity: "info", Timestamp: "2024-01-01T00:00:02Z",
+				Payload: map[string]any{"costUsd": 0.25, "usage": map[string]any{"totalTokens": 40}}},
+			{EventType: "tool_error", Severity: "error", Timestamp: "2024-01-01T00:00:03Z"},
+		}})
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+	s, err := c.GetSessionSummary(context.Background(), "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Computed {
+		t.Error("expected Computed=true")
+	}
+	if s.CallCount != 1 || s.TotalCostUsd != 0.25 || s.TotalTokens != 40 || s.ErrorCount != 1 || s.DurationMs != 3000 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+}
+
+func TestFailOpenSentinel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+		w.Write([]byte(`{"error":"server error"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithFailOpenSentinel(), WithRetry(RetryConfig{MaxRetries: 0}))
+	if c.LastError() != nil {
+		t.Fatal("expected no error before any request")
+	}
+	result, err := c.QueryEvents(context.Background(), nil)
+	if err != nil {
+		t.Errorf("fail-open should not return error, got: %v", err)
+	}
+	if !result.Suppressed {
+		t.Error("expected result to be marked suppressed")
+	}
+	if c.LastError() == nil {
+		t.Error("expected LastError to record the suppressed error")
+	}
+}
+
+func TestFailOpenWithoutSentinelDoesNotRecord(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithFailOpen(nil), WithRetry(RetryConfig{MaxRetries: 0}))
+	result, _ := c.QueryEvents(context.Background(), nil)
+	if result.Suppressed || c.LastError() != nil {
+		t.Error("default fail-open should not record suppressed errors")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	type call struct {
+		method, path string
+		body         any
+	}
+	var calls []call
+	c := NewClient("http://127.0.0.1:1", "key", WithDryRun(func(method, path string, body any) {
+		calls = append(calls, call{method, path, body})
+	}))
+
+	callID, err := c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{Provider: "openai", Model: "gpt-4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if callID == "" {
+		t.Error("expected generated callID in dry-run mode")
+	}
+	result, err := c.QueryEvents(context.Background(), nil)
+	if err != nil || result == nil {
+		t.Fatalf("expected zero-value result, got %v, %v", result, err)
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 sink calls, got %d", len(calls))
+	}
+	if calls[0].method != "POST" || calls[0].path != "/api/events" {
+		t.Errorf("unexpected first call: %+v", calls[0])
+	}
+	raw, ok := calls[0].body.(json.RawMessage)
+	if !ok {
+		t.Fatalf("expected serialized body, got %T", calls[0].body)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(raw, &body); err != nil || len(body["events"].([]any)) != 2 {
+		t.Errorf("unexpected body: %s", raw)
+	}
+	if calls[1].method != "GET" || calls[1].body != nil {
+		t.Errorf("unexpected second call: %+v", calls[1])
+	}
+}
+
+func TestQueryEventsFieldedSearch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want := "fields=id%2CeventType&metadata.userId=u1&payload.model=gpt-4&payload.provider=openai"
+		if r.URL.RawQuery != want {
+			t.Errorf("unexpected query:\n got %s\nwant %s", r.URL.RawQuery, want)
+		}
+		// The server ignores the filters and returns every event.
+		w.Write([]byte(`{"events":[
+			{"id":"e1","payload":{"model":"gpt-4","provider":"openai"},"metadata":{"userId":"u1"}},
+			{"id":"e2","payload":{"model":"gpt-4","provider":"openai"},"metadata":{"userId":"u2"}},
+			{"id":"e3","payload":{"model":"gpt-3.5","provider":"openai"},"metadata":{"userId":"u1"}},
+			{"id":"e4","payload":{"model":"gpt-4"},"metadata":{"userId":"u1"}}],"total":4}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key")
+	fields := "id,eventType"
+	r, err := c.QueryEvents(context.Background(), &EventQuery{
+		PayloadFilters:  map[string]string{"model": "gpt-4", "provider": "openai"},
+		MetadataFilters: map[string]string{"userId": "u1"},
+		Fields:          &fields,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Events) != 1 || r.Events[0].ID != "e1" {
+		t.Errorf("expected the filters to be applied locally, got %+v", r.Events)
+	}
+}
+
+func TestGetGuardrailStats(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/guardrails":
+			w.Write([]byte(`{"rules":[{"id":"g1","name":"cost"},{"id":"g2","name":"pii"}]}`))
+		case "/api/guardrails/history":
+			pages = append(pages, r.URL.Query().Get("offset"))
+			if r.URL.Query().Get("limit") != "200" {
+				t.Errorf("unexpected limit: %s", r.URL)
+			}
+			if r.URL.Query().Get("offset") != "0" {
+				w.Write([]byte(`{"triggers":[
+					{"id":"t5","ruleId":"g1","triggeredAt":"2023-12-31T00:00:00.000Z","actionResult":"pause_agent","metadata":{"agentId":"a1"}}],"total":201}`))
+				return
+			}
+			var b strings.Builder
+			b.WriteString(`{"total":201,"triggers":[`)
+			b.WriteString(`{"id":"t1","ruleId":"g1","triggeredAt":"2024-03-01T00:00:00.000Z","actionResult":"pause_agent","metadata":{"agentId":"a1"}},`)
+			b.WriteString(`{"id":"t2","ruleId":"g2","triggeredAt":"2024-01-03T00:00:00.000Z","actionResult":"dry_run","metadata":{"agentId":"a1","sessionId":"s1"}},`)
+			b.WriteString(`{"id":"t3","ruleId":"g1","triggeredAt":"2024-01-02T00:00:00.000Z","actionResult":"pause_agent","metadata":{"agentId":"a2"}},`)
+			for i := 3; i < 199; i++ {
+				b.WriteString(`{"id":"tx","ruleId":"g1","triggeredAt":"2024-01-02T00:00:00.000Z","actionResult":"dry_run","metadata":{"agentId":"a1"}},`)
+			}
+			b.WriteString(`{"id":"t4","ruleId":"g1","triggeredAt":"2024-01-01T12:00:00.000Z","actionResult":"pause_agent","metadata":{"agentId":"a1"}}]}`)
+			w.Write([]byte(b.String()))
+		default:
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+	agentID, from, to := "a1", "2024-01-01T00:00:00Z", "2024-02-01T00:00:00Z"
+	r, err := c.GetGuardrailStats(context.Background(), &GuardrailStatsOpts{AgentID: &agentID, From: &from, To: &to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 {
+		t.Errorf("expected to page until a trigger older than From, fetched offsets %v", pages)
+	}
+	if len(r.Rules) != 2 || r.TotalTriggers != 198 {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+	pii, cost := r.Rules[0], r.Rules[1]
+	if pii.RuleName != "pii" || pii.TriggerCount != 1 || pii.ActionCounts["dry_run"] != 1 ||
+		pii.LastTrigger.Action != "dry_run" || *pii.LastTrigger.SessionID != "s1" || *pii.LastTriggered != "2024-01-03T00:00:00.000Z" {
+		t.Errorf("unexpected pii stats: %+v", pii)
+	}
+	if cost.RuleName != "cost" || cost.TriggerCount != 197 || cost.ActionCounts["dry_run"] != 196 || cost.ActionCounts["pause_agent"] != 1 {
+		t.Errorf("unexpected cost stats: %+v", cost)
+	}
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"status":"ok","version":"` + strings.Repeat("x", 2048) + `"}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewClient(srv.URL, "key", WithMaxResponseBytes(1024)).Health(context.Background())
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("oversized responses should not be retried, got %d requests", requests)
+	}
+	if _, err := NewClient(srv.URL, "key").Health(context.Background()); err != nil {
+		t.Errorf("default limit should allow the response: %v", err)
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok","version":"1.0","uptimeSecs":5}`))
+	}))
+	defer srv.Close()
+
+	if _, err := NewClient(srv.URL, "key").Health(context.Background()); err != nil {
+		t.Fatalf("lenient decoding should ignore unknown fields: %v", err)
+	}
+	_, err := NewClient(srv.URL, "key", WithStrictDecoding()).Health(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `unknown field "uptimeSecs"`) || !strings.Contains(err.Error(), "/api/health") {
+		t.Errorf("expected an unknown field error naming the endpoint, got %v", err)
+	}
+}
+
+func TestGzipResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("expected Accept-Encoding gzip, got %q", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(`{"status":"ok","version":"1.2.3"}`))
+		gz.Close()
+	}))
+	defer srv.Close()
+
+	// A custom transport must not change how the body is decoded.
+	hc := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	for _, c := range []*Client{NewClient(srv.URL, "key"), NewClient(srv.URL, "key", WithHTTPClient(hc))} {
+		h, err := c.Health(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Version != "1.2.3" {
+			t.Errorf("expected decompressed body, got %+v", h)
+		}
+	}
+}
+
+func TestGetTimelines(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		if strings.Contains(r.URL.Path, "/missing/") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"Session not found"}`))
+			return
+		}
+		w.Write([]byte(`{"events":[{"id":"e1"}],"chainValid":true}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key")
+	ids := []string{"s1", "s2", "missing", "s3", "s4", "s1"}
+	results, errs := c.GetTimelines(context.Background(), ids, 2)
+	if len(results) != 4 || len(errs) != 1 {
+		t.Fatalf("expected 4 results and 1 error, got %d and %d", len(results), len(errs))
+	}
+	var nf *NotFoundError
+	if !errors.As(errs["missing"], &nf) {
+		t.Errorf("expected NotFoundError for missing session, got %v", errs["missing"])
+	}
+	if !results["s3"].ChainValid || len(results["s3"].Events) != 1 {
+		t.Errorf("unexpected timeline: %+v", results["s3"])
+	}
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", maxInFlight)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, errs = c.GetTimelines(ctx, []string{"s1", "s2"}, 0)
+	if len(results) != 0 || len(errs) != 2 {
+		t.Errorf("expected cancelled context to fail every session, got %v / %v", results, errs)
+	}
+}
+
+func TestEnvironmentAndTenant(t *testing.T) {
+	var seen []string
+	var events []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Method+" "+r.Header.Get("X-AgentLens-Env")+" "+r.Header.Get("X-Tenant-ID"))
+		if r.Method == http.MethodPost {
+			var body struct {
+				Events []Event `json:"events"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			events = body.Events
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithEnvironment("staging"), WithTenant("t1"), WithDefaultMetadata(map[string]any{"tenantId": "override"}))
+	if _, err := c.Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendEvents(context.Background(), []Event{{SessionID: "s1", AgentID: "a1", EventType: "custom"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 2 || seen[0] != "GET staging t1" || seen[1] != "POST staging t1" {
+		t.Errorf("expected tagging headers on GET and POST, got %q", seen)
+	}
+	if len(events) != 1 || events[0].Metadata["environment"] != "staging" || events[0].Metadata["tenantId"] != "override" {
+		t.Errorf("unexpected event metadata: %+v", events)
+	}
+}
+
+func TestClientName(t *testing.T) {
+	var headers []string
+	var events []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("X-App-Name"))
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		events = append(events, body.Events...)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithClientName("billing"), WithBatching(WithFlushInterval(time.Hour)))
+	c.LogEvent(context.Background(), "s1", "a1", "custom", "info", nil)
+	c.LogEvent(context.Background(), "s1", "a1", "custom", "info", nil)
+	if err := c.SendEvents(context.Background(), []Event{
+		{SessionID: "s1", AgentID: "a1", EventType: "custom", Metadata: map[string]any{"appName": "explicit"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(headers) != 2 || headers[0] != "billing" || headers[1] != "billing" {
+		t.Errorf("expected X-App-Name on every request, got %q", headers)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if events[0].Metadata["appName"] != "explicit" {
+		t.Errorf("explicit metadata should win, got %v", events[0].Metadata)
+	}
+	for _, e := range events[1:] {
+		if e.Metadata["appName"] != "billing" {
+			t.Errorf("batched event missing appName: %v", e.Metadata)
+		}
 	}
 }
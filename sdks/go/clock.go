@@ -0,0 +1,38 @@
+package agentlens
+
+import "time"
+
+// clock abstracts the time source so tests can control timestamps, latency
+// measurements and flush ticks deterministically.
+type clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) ticker
+}
+
+// ticker is the subset of *time.Ticker used by the SDK.
+type ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realClock is the default clock backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTicker(d time.Duration) ticker { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct{ t *time.Ticker }
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+func (r realTicker) Stop()               { r.t.Stop() }
+
+// withClock replaces the client's time source. Used by tests.
+func withClock(clk clock) ClientOption {
+	return func(c *clientConfig) { c.clock = clk }
+}
+
+// withBatchClock replaces the batch sender's time source. Used by tests.
+func withBatchClock(clk clock) BatchOption {
+	return func(c *batchConfig) { c.clock = clk }
+}
@@ -0,0 +1,146 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for deterministic tests.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+func newFakeClock(t time.Time) *fakeClock { return &fakeClock{now: t} }
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) NewTicker(d time.Duration) ticker {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTicker{c: make(chan time.Time, 1), period: d, next: f.now.Add(d)}
+	f.tickers = append(f.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward and fires any tickers that came due.
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	for _, t := range f.tickers {
+		t.fire(f.now)
+	}
+}
+
+type fakeTicker struct {
+	mu      sync.Mutex
+	c       chan time.Time
+	period  time.Duration
+	next    time.Time
+	stopped bool
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {
+	t.mu.Lock()
+	t.stopped = true
+	t.mu.Unlock()
+}
+
+func (t *fakeTicker) fire(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped || now.Before(t.next) {
+		return
+	}
+	for !now.Before(t.next) {
+		t.next = t.next.Add(t.period)
+	}
+	// Like time.Ticker, drop ticks for slow receivers.
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+func TestLogLlmCallUsesClock(t *testing.T) {
+	var body struct {
+		Events []map[string]any `json:"events"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	clk := newFakeClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	c := NewClient(srv.URL, "key", withClock(clk))
+	if _, err := c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{Provider: "openai", Model: "gpt-4"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Events) == 0 || body.Events[0]["timestamp"] != "2024-01-02T03:04:05Z" {
+		t.Errorf("expected timestamp from fake clock, got %+v", body.Events)
+	}
+}
+
+func TestStartLlmCallLatencyUsesClock(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	clk := newFakeClock(time.Unix(1700000000, 0))
+	c := NewClient(srv.URL, "key", withClock(clk))
+	h, err := c.StartLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{Provider: "openai", Model: "gpt-4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(250 * time.Millisecond)
+	h.AppendDelta("hi")
+	if got := h.FirstTokenLatency(); got != 250*time.Millisecond {
+		t.Errorf("expected 250ms first-token latency, got %v", got)
+	}
+}
+
+func TestBatchFlushUsesClock(t *testing.T) {
+	sent := make(chan int, 1)
+	clk := newFakeClock(time.Unix(1700000000, 0))
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		sent <- len(events)
+		return nil
+	}, WithFlushInterval(time.Minute), withBatchClock(clk))
+	defer bs.Shutdown(context.Background())
+
+	bs.Enqueue(Event{ID: "e1"})
+	clk.Advance(30 * time.Second)
+	select {
+	case <-sent:
+		t.Fatal("flushed before the interval elapsed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clk.Advance(30 * time.Second)
+	select {
+	case n := <-sent:
+		if n != 1 {
+			t.Errorf("expected 1 event, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a flush after the interval elapsed")
+	}
+	if got := bs.Stats().LastFlushTime; !got.Equal(clk.Now()) {
+		t.Errorf("expected LastFlushTime %v, got %v", clk.Now(), got)
+	}
+}
@@ -0,0 +1,86 @@
+package agentlens
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ContextOption configures a query built by NewContextQuery.
+type ContextOption func(*ContextQuery)
+
+// NewContextQuery returns a ContextQuery for topic with opts applied.
+func NewContextQuery(topic string, opts ...ContextOption) *ContextQuery {
+	q := &ContextQuery{Topic: topic}
+	for _, o := range opts {
+		o(q)
+	}
+	return q
+}
+
+// WithContextAgent limits context to the agent's sessions.
+func WithContextAgent(agentID string) ContextOption {
+	return func(q *ContextQuery) { q.AgentID = &agentID }
+}
+
+// WithContextUser limits context to the user's sessions.
+func WithContextUser(userID string) ContextOption {
+	return func(q *ContextQuery) { q.UserID = &userID }
+}
+
+// WithContextRange limits context to sessions between from and to (RFC
+// 3339). An empty bound is left open.
+func WithContextRange(from, to string) ContextOption {
+	return func(q *ContextQuery) {
+		if from != "" {
+			q.From = &from
+		}
+		if to != "" {
+			q.To = &to
+		}
+	}
+}
+
+// WithContextLimit sets the maximum number of sessions.
+func WithContextLimit(n int) ContextOption {
+	return func(q *ContextQuery) { q.Limit = &n }
+}
+
+// ContextEntry is a flattened view of a ContextSession.
+type ContextEntry struct {
+	SessionID string
+	// Topic is the topic that was queried.
+	Topic string
+	// Snippet is the session's summary or, without one, its key event
+	// summaries joined with "; ".
+	Snippet string
+	// Relevance is the session's relevance score.
+	Relevance float64
+	// Timestamp is when the session started.
+	Timestamp string
+	// Raw is the session as returned by the server.
+	Raw json.RawMessage
+}
+
+// contextEntries flattens r's sessions, keeping their order.
+func contextEntries(r *ContextResult) []ContextEntry {
+	if len(r.Sessions) == 0 {
+		return nil
+	}
+	entries := make([]ContextEntry, len(r.Sessions))
+	for i, s := range r.Sessions {
+		e := ContextEntry{SessionID: s.SessionID, Topic: r.Topic, Relevance: s.RelevanceScore, Timestamp: s.StartedAt, Raw: s.Raw}
+		if s.Summary != nil && *s.Summary != "" {
+			e.Snippet = *s.Summary
+		} else {
+			parts := make([]string, 0, len(s.KeyEvents))
+			for _, k := range s.KeyEvents {
+				if k.Summary != "" {
+					parts = append(parts, k.Summary)
+				}
+			}
+			e.Snippet = strings.Join(parts, "; ")
+		}
+		entries[i] = e
+	}
+	return entries
+}
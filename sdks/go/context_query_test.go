@@ -0,0 +1,40 @@
+package agentlens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetContextEntries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("topic") != "billing" || q.Get("agentId") != "a1" || q.Get("from") != "2024-01-01T00:00:00Z" || q.Has("to") || q.Get("limit") != "5" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"topic":"billing","totalSessions":2,"lessons":[],"sessions":[
+			{"sessionId":"s1","agentId":"a1","startedAt":"2024-01-02T00:00:00Z","summary":"refund issued","relevanceScore":0.9,"keyEvents":[]},
+			{"sessionId":"s2","agentId":"a1","startedAt":"2024-01-01T00:00:00Z","relevanceScore":0.4,"keyEvents":[
+				{"id":"e1","eventType":"tool_call","summary":"lookup invoice","timestamp":"t"},
+				{"id":"e2","eventType":"tool_error","summary":"card declined","timestamp":"t"}]}]}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	q := NewContextQuery("billing", WithContextAgent("a1"), WithContextRange("2024-01-01T00:00:00Z", ""), WithContextLimit(5))
+	r, err := c.GetContext(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", r.Entries)
+	}
+	e := r.Entries[0]
+	if e.SessionID != "s1" || e.Topic != "billing" || e.Snippet != "refund issued" || e.Relevance != 0.9 || e.Timestamp != "2024-01-02T00:00:00Z" || len(e.Raw) == 0 {
+		t.Errorf("unexpected first entry: %+v", e)
+	}
+	if got := r.Entries[1].Snippet; got != "lookup invoice; card declined" {
+		t.Errorf("expected key event summaries as snippet, got %q", got)
+	}
+}
@@ -0,0 +1,54 @@
+package agentlens
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// dropReport aggregates queue overflow drops for WithDropReportInterval.
+type dropReport struct {
+	mu         sync.Mutex
+	pending    int64
+	lastReport time.Time
+}
+
+// WithDropReportInterval reports queue overflow drops to the error callback
+// at most once per d, as one summary such as "queue overflow: dropped 4213
+// oldest event(s) in the last 10s", instead of once per overflowing
+// Enqueue. The first drop after a quiet interval is reported at once;
+// drops still unreported are reported on a later flush tick or at
+// Shutdown. BatchStats.TotalDropped still counts every drop. The default
+// (0) reports each overflow.
+func WithDropReportInterval(d time.Duration) BatchOption {
+	return func(c *batchConfig) { c.dropReportInterval = d }
+}
+
+// reportOverflow reports n events dropped on queue overflow.
+func (b *BatchSender) reportOverflow(n int) {
+	if b.cfg.dropReportInterval <= 0 {
+		b.reportError(fmt.Errorf("queue overflow: dropped %d oldest event(s)", n))
+		return
+	}
+	b.drops.mu.Lock()
+	b.drops.pending += int64(n)
+	b.drops.mu.Unlock()
+	b.flushDropReport(false)
+}
+
+// flushDropReport reports the aggregated drops if the interval has passed
+// since the last report, or regardless if force is set.
+func (b *BatchSender) flushDropReport(force bool) {
+	b.drops.mu.Lock()
+	now := b.cfg.clock.Now()
+	since := now.Sub(b.drops.lastReport)
+	if b.drops.pending == 0 || !force && since < b.cfg.dropReportInterval {
+		b.drops.mu.Unlock()
+		return
+	}
+	n := b.drops.pending
+	b.drops.pending = 0
+	b.drops.lastReport = now
+	b.drops.mu.Unlock()
+	b.reportError(fmt.Errorf("queue overflow: dropped %d oldest event(s) in the last %v", n, since.Round(time.Millisecond)))
+}
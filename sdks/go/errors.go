@@ -1,6 +1,9 @@
 package agentlens
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // APIError is the base error type for all AgentLens SDK errors.
 type APIError struct {
@@ -8,15 +11,47 @@ type APIError struct {
 	Status  int    `json:"status"`
 	Code    string `json:"code"`
 	Details any    `json:"details,omitempty"`
+	// RequestID is the server's X-Request-ID response header, if any.
+	// Include it when reporting problems so server logs can be correlated.
+	RequestID string `json:"requestId,omitempty"`
+	// Context identifies what the failed call was for, e.g. "sessionId" and
+	// "agentId" for logging calls or "batchSize" for SendEvents, so errors
+	// reported through fail-open handlers can be correlated.
+	Context map[string]string `json:"context,omitempty"`
 }
 
+// apiError gives callers generic access to the APIError embedded in every typed error.
+func (e *APIError) apiError() *APIError { return e }
+
 func (e *APIError) Error() string {
+	if e.Status > 0 && e.RequestID != "" {
+		return fmt.Sprintf("agentlens: %s (HTTP %d, code=%s, request_id=%s)", e.Message, e.Status, e.Code, e.RequestID)
+	}
 	if e.Status > 0 {
 		return fmt.Sprintf("agentlens: %s (HTTP %d, code=%s)", e.Message, e.Status, e.Code)
 	}
 	return fmt.Sprintf("agentlens: %s (code=%s)", e.Message, e.Code)
 }
 
+// withErrorContext adds the key/value pairs kv to the Context of the
+// APIError in err, keeping existing keys, and returns err.
+func withErrorContext(err error, kv ...string) error {
+	var e interface{ apiError() *APIError }
+	if !errors.As(err, &e) {
+		return err
+	}
+	ae := e.apiError()
+	if ae.Context == nil {
+		ae.Context = make(map[string]string, len(kv)/2)
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		if _, ok := ae.Context[kv[i]]; !ok {
+			ae.Context[kv[i]] = kv[i+1]
+		}
+	}
+	return err
+}
+
 // AuthenticationError is returned when the server responds with 401.
 type AuthenticationError struct{ *APIError }
 
@@ -26,6 +61,41 @@ type NotFoundError struct{ *APIError }
 // ValidationError is returned when the server responds with 400.
 type ValidationError struct{ *APIError }
 
+// FieldErrors returns the per-field messages in Details, keyed by field
+// path (e.g. "events.0.sessionId"), for mapping errors to form fields.
+// Messages for the same field are joined with "; ". It returns nil if
+// Details is not a list of {field or path, message} objects.
+func (e *ValidationError) FieldErrors() map[string]string {
+	var items []struct {
+		Field   string  `json:"field"`
+		Path    string  `json:"path"`
+		Message *string `json:"message"`
+	}
+	if e.Details == nil || remarshal(e.Details, &items) != nil || len(items) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(items))
+	for _, it := range items {
+		if it.Message == nil {
+			return nil
+		}
+		field := it.Field
+		if field == "" {
+			field = it.Path
+		}
+		if prev, ok := out[field]; ok {
+			out[field] = prev + "; " + *it.Message
+		} else {
+			out[field] = *it.Message
+		}
+	}
+	return out
+}
+
+// ErrBatchSenderClosed is reported for events enqueued on a BatchSender
+// after Shutdown has begun. They are discarded instead of sent.
+var ErrBatchSenderClosed = errors.New("agentlens: batch sender is shut down")
+
 // ConnectionError is returned on network failures, DNS errors, or timeouts.
 type ConnectionError struct {
 	*APIError
@@ -46,7 +116,19 @@ type RateLimitError struct {
 type QuotaExceededError struct{ *APIError }
 
 // BackpressureError is returned when the server responds with 503.
-type BackpressureError struct{ *APIError }
+type BackpressureError struct {
+	*APIError
+	// RetryAfter is the number of seconds to wait before retrying, if provided by the server.
+	RetryAfter *float64
+}
+
+// ServerError is returned when the server responds with 500 or another
+// 5xx status not covered by a more specific error type.
+type ServerError struct{ *APIError }
+
+// GatewayError is returned when the server responds with 502 or 504,
+// typically from a proxy during a server restart.
+type GatewayError struct{ *APIError }
 
 // newAPIError creates a base APIError.
 func newAPIError(message string, status int, code string, details any) *APIError {
@@ -66,9 +148,30 @@ func mapHTTPError(status int, message string, details any, retryAfterSec *float6
 		return &NotFoundError{newAPIError(message, status, "NOT_FOUND", details)}
 	case 429:
 		return &RateLimitError{APIError: newAPIError(message, status, "RATE_LIMIT", details), RetryAfter: retryAfterSec}
+	case 502, 504:
+		return &GatewayError{newAPIError(message, status, "GATEWAY_ERROR", details)}
 	case 503:
-		return &BackpressureError{newAPIError(message, status, "BACKPRESSURE", details)}
+		return &BackpressureError{APIError: newAPIError(message, status, "BACKPRESSURE", details), RetryAfter: retryAfterSec}
 	default:
+		if status >= 500 {
+			return &ServerError{newAPIError(message, status, "SERVER_ERROR", details)}
+		}
 		return newAPIError(message, status, "API_ERROR", details)
 	}
 }
+
+// IsRetryable reports whether err is a transient failure that the client
+// would retry: connection errors, 429, 502, 503, and 504.
+func IsRetryable(err error) bool {
+	return shouldRetry(err)
+}
+
+// StatusCode returns the HTTP status code carried by an SDK error, or 0 if
+// err is not an SDK error or no response was received.
+func StatusCode(err error) int {
+	var e interface{ apiError() *APIError }
+	if errors.As(err, &e) {
+		return e.apiError().Status
+	}
+	return 0
+}
@@ -1,7 +1,9 @@
 package agentlens
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -17,7 +19,11 @@ func TestErrorMapping(t *testing.T) {
 		{404, func(e error) bool { var v *NotFoundError; return errors.As(e, &v) }, "NotFoundError"},
 		{429, func(e error) bool { var v *RateLimitError; return errors.As(e, &v) }, "RateLimitError"},
 		{503, func(e error) bool { var v *BackpressureError; return errors.As(e, &v) }, "BackpressureError"},
-		{500, func(e error) bool { var v *APIError; return errors.As(e, &v) }, "GenericError"},
+		{502, func(e error) bool { var v *GatewayError; return errors.As(e, &v) }, "GatewayError502"},
+		{504, func(e error) bool { var v *GatewayError; return errors.As(e, &v) }, "GatewayError504"},
+		{500, func(e error) bool { var v *ServerError; return errors.As(e, &v) }, "ServerError"},
+		{501, func(e error) bool { var v *ServerError; return errors.As(e, &v) }, "ServerError501"},
+		{418, func(e error) bool { var v *APIError; return errors.As(e, &v) }, "GenericError"},
 	}
 
 	for _, tt := range tests {
@@ -42,6 +48,18 @@ func TestRateLimitRetryAfter(t *testing.T) {
 	}
 }
 
+func TestBackpressureRetryAfter(t *testing.T) {
+	ra := 3.0
+	err := mapHTTPError(503, "busy", nil, &ra)
+	var bp *BackpressureError
+	if !errors.As(err, &bp) {
+		t.Fatal("expected BackpressureError")
+	}
+	if bp.RetryAfter == nil || *bp.RetryAfter != 3 {
+		t.Errorf("expected RetryAfter=3, got %v", bp.RetryAfter)
+	}
+}
+
 func TestConnectionErrorUnwrap(t *testing.T) {
 	cause := errors.New("dns lookup failed")
 	err := &ConnectionError{
@@ -60,3 +78,49 @@ func TestErrorInterface(t *testing.T) {
 		t.Error("error message should not be empty")
 	}
 }
+
+func TestStatusCode(t *testing.T) {
+	for _, status := range []int{400, 401, 402, 404, 418, 429, 500, 502, 503, 504} {
+		err := fmt.Errorf("wrapped: %w", mapHTTPError(status, "test", nil, nil))
+		if got := StatusCode(err); got != status {
+			t.Errorf("StatusCode() = %d, want %d", got, status)
+		}
+	}
+	if got := StatusCode(errors.New("plain")); got != 0 {
+		t.Errorf("expected 0 for non-SDK error, got %d", got)
+	}
+	connErr := &ConnectionError{APIError: newAPIError("timeout", 0, "CONNECTION_ERROR", nil)}
+	if got := StatusCode(connErr); got != 0 {
+		t.Errorf("expected 0 for ConnectionError, got %d", got)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	if !IsRetryable(mapHTTPError(502, "gw", nil, nil)) {
+		t.Error("502 should be retryable")
+	}
+	if IsRetryable(mapHTTPError(500, "ise", nil, nil)) {
+		t.Error("500 should not be retryable")
+	}
+}
+
+func TestValidationErrorFieldErrors(t *testing.T) {
+	var details any
+	json.Unmarshal([]byte(`[{"path":"events.0.sessionId","message":"Required"},{"path":"events.0.sessionId","message":"Too short"},{"path":"name","message":"Invalid"}]`), &details)
+	ve := mapHTTPError(400, "Validation failed", details, nil).(*ValidationError)
+	got := ve.FieldErrors()
+	if len(got) != 2 || got["events.0.sessionId"] != "Required; Too short" || got["name"] != "Invalid" {
+		t.Errorf("unexpected field errors: %v", got)
+	}
+
+	if got := newFieldValidationError("minScore", "must be between 0 and 1").FieldErrors(); got["minScore"] != "must be between 0 and 1" {
+		t.Errorf("unexpected client-side field errors: %v", got)
+	}
+
+	for _, d := range []any{nil, "bad input", []any{"x"}, []any{map[string]any{"path": "a"}}} {
+		ve := &ValidationError{newAPIError("Validation failed", 400, "VALIDATION_ERROR", d)}
+		if got := ve.FieldErrors(); got != nil {
+			t.Errorf("details %v: expected nil, got %v", d, got)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package agentlens
+
+// Event severities accepted by the server, for Event.Severity. An empty
+// Severity defaults to SeverityInfo server-side.
+const (
+	SeverityDebug    = "debug"
+	SeverityInfo     = "info"
+	SeverityWarn     = "warn"
+	SeverityError    = "error"
+	SeverityCritical = "critical"
+)
+
+// Event types accepted by the server, for Event.EventType.
+const (
+	EventTypeSessionStarted    = "session_started"
+	EventTypeSessionEnded      = "session_ended"
+	EventTypeToolCall          = "tool_call"
+	EventTypeToolResponse      = "tool_response"
+	EventTypeToolError         = "tool_error"
+	EventTypeApprovalRequested = "approval_requested"
+	EventTypeApprovalGranted   = "approval_granted"
+	EventTypeApprovalDenied    = "approval_denied"
+	EventTypeApprovalExpired   = "approval_expired"
+	EventTypeFormSubmitted     = "form_submitted"
+	EventTypeFormCompleted     = "form_completed"
+	EventTypeFormExpired       = "form_expired"
+	EventTypeCostTracked       = "cost_tracked"
+	EventTypeLlmCall           = "llm_call"
+	EventTypeLlmResponse       = "llm_response"
+	EventTypeAlertTriggered    = "alert_triggered"
+	EventTypeAlertResolved     = "alert_resolved"
+	EventTypeRetrieval         = "retrieval"
+	EventTypeEmbedding         = "embedding"
+	EventTypeChainStep         = "chain_step"
+	EventTypeCustom            = "custom"
+)
+
+// ValidSeverity reports whether s is a severity the server accepts. The
+// empty string is not valid here, although Event.Validate allows it.
+func ValidSeverity(s string) bool {
+	switch s {
+	case SeverityDebug, SeverityInfo, SeverityWarn, SeverityError, SeverityCritical:
+		return true
+	}
+	return false
+}
+
+// isErrorSeverity reports whether s marks an error: SeverityError or
+// SeverityCritical.
+func isErrorSeverity(s string) bool {
+	return s == SeverityError || s == SeverityCritical
+}
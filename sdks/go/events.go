@@ -0,0 +1,191 @@
+package agentlens
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ingestResult is the POST /api/events response body.
+type ingestResult struct {
+	Ingested int `json:"ingested"`
+	Events   []struct {
+		ID   string `json:"id"`
+		Hash string `json:"hash"`
+	} `json:"events"`
+}
+
+// LogEvent sends a single event and returns the ID the server assigned to
+// it. With WithBatching, the event is queued instead and the returned ID is
+// empty. An empty severity means "info".
+func (c *Client) LogEvent(ctx context.Context, sessionID, agentID, eventType, severity string, payload map[string]any) (string, error) {
+	if severity == "" {
+		severity = SeverityInfo
+	}
+	if !c.keepEvent(sessionID, agentID, severity) {
+		return "", nil
+	}
+	e := sdkEvent(sessionID, agentID, eventType, severity, payload, c.cfg.clock.Now().UTC().Format(time.RFC3339Nano))
+	if c.enqueueBatched(e) {
+		return "", nil
+	}
+	var result ingestResult
+	if err := c.postEvents(ctx, sessionID, agentID, []Event{e}, &result); err != nil {
+		return "", err
+	}
+	if len(result.Events) == 0 {
+		return "", nil
+	}
+	return result.Events[0].ID, nil
+}
+
+// LogError logs err as a custom event of type "error" with severity error.
+// The payload data holds the message and Go error type. If formatting err
+// with %+v adds detail beyond err.Error(), as with errors that carry a stack
+// trace, that output is included as "stack". A nil err is a no-op.
+func (c *Client) LogError(ctx context.Context, sessionID, agentID string, err error) (string, error) {
+	if err == nil {
+		return "", nil
+	}
+	data := map[string]any{
+		"message":   err.Error(),
+		"errorType": fmt.Sprintf("%T", err),
+	}
+	if detail := fmt.Sprintf("%+v", err); detail != err.Error() {
+		data["stack"] = detail
+	}
+	payload := map[string]any{"type": "error", "data": data}
+	return c.LogEvent(ctx, sessionID, agentID, EventTypeCustom, SeverityError, payload)
+}
+
+// EventsIterator pages through events matching a query.
+type EventsIterator struct {
+	c      *Client
+	ctx    context.Context
+	q      EventQuery
+	cursor string
+	offset int
+	page   []Event
+	idx    int
+	cur    Event
+	err    error
+	done   bool
+}
+
+// EventsIterator returns an iterator over all events matching q. q.Limit
+// sets the page size (default 100). When the server returns a NextCursor,
+// pages are fetched by cursor, which stays consistent while new events
+// arrive; otherwise the iterator falls back to advancing Offset.
+//
+//	it := client.EventsIterator(ctx, &agentlens.EventQuery{SessionID: &id})
+//	for it.Next() {
+//	    e := it.Event()
+//	}
+//	if err := it.Err(); err != nil { ... }
+func (c *Client) EventsIterator(ctx context.Context, q *EventQuery) *EventsIterator {
+	it := &EventsIterator{c: c, ctx: ctx}
+	if q != nil {
+		it.q = *q
+	}
+	if it.q.Cursor != nil {
+		it.cursor = *it.q.Cursor
+	}
+	if it.q.Offset != nil {
+		it.offset = *it.q.Offset
+	}
+	if it.q.Limit == nil {
+		limit := 100
+		it.q.Limit = &limit
+	}
+	return it
+}
+
+// Next advances to the next event, fetching a new page when needed.
+// It returns false when iteration is complete or an error occurred.
+func (it *EventsIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	for it.idx >= len(it.page) {
+		if it.done || !it.fetch() {
+			return false
+		}
+	}
+	it.cur = it.page[it.idx]
+	it.idx++
+	return true
+}
+
+func (it *EventsIterator) fetch() bool {
+	q := it.q
+	if it.cursor != "" {
+		cursor := it.cursor
+		q.Cursor, q.Offset = &cursor, nil
+	} else {
+		offset := it.offset
+		q.Cursor, q.Offset = nil, &offset
+	}
+	var result EventQueryResult
+	if err := it.c.do(it.ctx, http.MethodGet, eventsPath(&q), nil, &result, false); err != nil {
+		it.err = it.c.failOpen(err, nil)
+		it.done = true
+		return false
+	}
+	it.idx = 0
+	it.offset += len(result.Events)
+	it.cursor = result.NextCursor
+	if len(result.Events) == 0 || (result.NextCursor == "" && !result.HasMore) {
+		it.done = true
+	}
+	it.page = it.q.filter(result.Events)
+	return true
+}
+
+// Event returns the current event. Only valid after Next returns true.
+func (it *EventsIterator) Event() Event { return it.cur }
+
+// Err returns the error that stopped iteration, if any.
+func (it *EventsIterator) Err() error { return it.err }
+
+// QueryEventsMulti runs several QueryEvents calls in parallel, at most
+// concurrency at a time (default 8), e.g. one query per agent for a fleet
+// dashboard. results[i] and errs[i] belong to queries[i]; errs is nil if
+// every query succeeded. Once ctx is cancelled, queries that have not
+// started fail with a *ConnectionError instead of being sent.
+func (c *Client) QueryEventsMulti(ctx context.Context, queries []*EventQuery, concurrency int) (results []*EventQueryResult, errs []error) {
+	if concurrency <= 0 {
+		concurrency = getEventsConcurrency
+	}
+	results = make([]*EventQueryResult, len(queries))
+	allErrs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, q := range queries {
+		wg.Add(1)
+		go func(i int, q *EventQuery) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				allErrs[i] = &ConnectionError{
+					APIError: newAPIError(ctx.Err().Error(), 0, "CONNECTION_ERROR", nil),
+					Cause:    ctx.Err(),
+				}
+				return
+			}
+			results[i], allErrs[i] = c.QueryEvents(ctx, q)
+		}(i, q)
+	}
+	wg.Wait()
+	for _, err := range allErrs {
+		if err != nil {
+			return results, allErrs
+		}
+	}
+	return results, nil
+}
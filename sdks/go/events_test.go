@@ -0,0 +1,265 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stackError mimics errors that print a stack trace with %+v.
+type stackError struct{ msg string }
+
+func (e *stackError) Error() string { return e.msg }
+
+func (e *stackError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%s\nmain.run\n\tmain.go:12", e.msg)
+		return
+	}
+	fmt.Fprint(s, e.msg)
+}
+
+func TestLogEvent(t *testing.T) {
+	var last map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []map[string]any `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		last = body.Events[0]
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"ingested":1,"events":[{"id":"01HX","hash":"abc"}]}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	id, err := c.LogEvent(context.Background(), "s1", "a1", "custom", "", map[string]any{"type": "note", "data": map[string]any{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "01HX" {
+		t.Errorf("expected server-assigned ID, got %q", id)
+	}
+	if last["severity"] != "info" || last["eventType"] != "custom" || last["sessionId"] != "s1" {
+		t.Errorf("unexpected event: %v", last)
+	}
+
+	if _, err := c.LogError(context.Background(), "s1", "a1", errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	data := last["payload"].(map[string]any)["data"].(map[string]any)
+	if last["severity"] != "error" || data["message"] != "boom" {
+		t.Errorf("unexpected error event: %v", last)
+	}
+	if _, ok := data["stack"]; ok {
+		t.Error("plain errors should not carry a stack")
+	}
+
+	if _, err := c.LogError(context.Background(), "s1", "a1", &stackError{msg: "boom"}); err != nil {
+		t.Fatal(err)
+	}
+	data = last["payload"].(map[string]any)["data"].(map[string]any)
+	if data["stack"] != "boom\nmain.run\n\tmain.go:12" {
+		t.Errorf("expected %%+v output as stack, got %v", data["stack"])
+	}
+}
+
+func TestLogEventUsesBatcher(t *testing.T) {
+	var sent []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, body.Events...)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key", WithBatching())
+
+	id, err := c.LogEvent(context.Background(), "s1", "a1", "custom", "warn", nil)
+	if err != nil || id != "" {
+		t.Fatalf("expected enqueue with empty ID, got %q, %v", id, err)
+	}
+	if len(sent) != 0 {
+		t.Fatal("expected no request before Close")
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0].Severity != "warn" {
+		t.Errorf("expected one batched warn event, got %+v", sent)
+	}
+}
+
+func TestEventsIteratorCursor(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		queries = append(queries, "cursor="+q.Get("cursor")+" offset="+q.Get("offset"))
+		switch q.Get("cursor") {
+		case "":
+			w.Write([]byte(`{"events":[{"id":"e1"},{"id":"e2"}],"total":3,"hasMore":true,"nextCursor":"c2"}`))
+		case "c2":
+			w.Write([]byte(`{"events":[{"id":"e3"}],"total":3,"hasMore":false}`))
+		}
+	}))
+	defer srv.Close()
+
+	limit := 2
+	it := NewClient(srv.URL, "key").EventsIterator(context.Background(), &EventQuery{Limit: &limit})
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Event().ID)
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if len(ids) != 3 || ids[2] != "e3" {
+		t.Errorf("unexpected events: %v", ids)
+	}
+	if len(queries) != 2 || queries[0] != "cursor= offset=0" || queries[1] != "cursor=c2 offset=" {
+		t.Errorf("expected cursor paging after the first page, got %q", queries)
+	}
+}
+
+func TestEventsIteratorOffsetFallback(t *testing.T) {
+	var offsets []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+		switch offset {
+		case "0":
+			w.Write([]byte(`{"events":[{"id":"e1"},{"id":"e2"}],"total":3,"hasMore":true}`))
+		default:
+			w.Write([]byte(`{"events":[{"id":"e3"}],"total":3,"hasMore":false}`))
+		}
+	}))
+	defer srv.Close()
+
+	limit := 2
+	it := NewClient(srv.URL, "key").EventsIterator(context.Background(), &EventQuery{Limit: &limit})
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if it.Err() != nil || n != 3 {
+		t.Fatalf("expected 3 events, got %d (%v)", n, it.Err())
+	}
+	if len(offsets) != 2 || offsets[1] != "2" {
+		t.Errorf("expected offset paging, got %v", offsets)
+	}
+}
+
+func TestEventsIteratorFilters(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("offset") {
+		case "0":
+			w.Write([]byte(`{"events":[{"id":"e1","payload":{"n":1}},{"id":"e2","payload":{"n":2}}],"total":5,"hasMore":true}`))
+		case "2":
+			w.Write([]byte(`{"events":[{"id":"e3","payload":{"n":3}},{"id":"e4","payload":{"n":4}}],"total":5,"hasMore":true}`))
+		default:
+			w.Write([]byte(`{"events":[{"id":"e5","payload":{"n":1}}],"total":5,"hasMore":false}`))
+		}
+	}))
+	defer srv.Close()
+
+	limit := 2
+	it := NewClient(srv.URL, "key").EventsIterator(context.Background(), &EventQuery{
+		Limit:          &limit,
+		PayloadFilters: map[string]string{"n": "1"},
+	})
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Event().ID)
+	}
+	if it.Err() != nil || len(ids) != 2 || ids[0] != "e1" || ids[1] != "e5" {
+		t.Fatalf("expected e1 and e5, skipping the page with no matches, got %v (%v)", ids, it.Err())
+	}
+}
+
+func TestCountEvents(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.RawQuery
+		w.Write([]byte(`{"events":[{"id":"e1"}],"total":42,"hasMore":true}`))
+	}))
+	defer srv.Close()
+
+	sessionID, limit := "s1", 500
+	n, err := NewClient(srv.URL, "key").CountEvents(context.Background(), &EventQuery{SessionID: &sessionID, Limit: &limit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 42 {
+		t.Errorf("expected 42, got %d", n)
+	}
+	if got != "fields=id&limit=1&sessionId=s1" {
+		t.Errorf("unexpected query: %s", got)
+	}
+}
+
+func TestQueryEventsMulti(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		agent := r.URL.Query().Get("agentId")
+		if agent == "missing" {
+			w.WriteHeader(404)
+			w.Write([]byte(`{"error":"not found"}`))
+			return
+		}
+		fmt.Fprintf(w, `{"events":[{"id":"e-%s","agentId":%q}],"total":1}`, agent, agent)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key")
+	var queries []*EventQuery
+	for _, a := range []string{"a0", "a1", "missing", "a3", "a4", "a5"} {
+		a := a
+		queries = append(queries, &EventQuery{AgentID: &a})
+	}
+	results, errs := c.QueryEventsMulti(context.Background(), queries, 2)
+	if len(results) != 6 || len(errs) != 6 {
+		t.Fatalf("expected aligned slices, got %d results and %d errors", len(results), len(errs))
+	}
+	for i, res := range results {
+		if i == 2 {
+			var nf *NotFoundError
+			if !errors.As(errs[i], &nf) {
+				t.Errorf("query 2: expected NotFoundError, got %v", errs[i])
+			}
+			continue
+		}
+		if errs[i] != nil || res.Events[0].AgentID != *queries[i].AgentID {
+			t.Errorf("query %d: result %+v, err %v", i, res, errs[i])
+		}
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("expected at most 2 concurrent requests, saw %d", p)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, errs = c.QueryEventsMulti(ctx, queries, 2)
+	for i, err := range errs {
+		var ce *ConnectionError
+		if !errors.As(err, &ce) {
+			t.Errorf("query %d: expected ConnectionError after cancellation, got %v", i, err)
+		}
+	}
+}
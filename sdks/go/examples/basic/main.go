@@ -48,7 +48,7 @@ func main() {
 
 	// BatchSender for high-throughput
 	bs := agentlens.NewBatchSender(client.SendEvents, agentlens.WithMaxBatchSize(50))
-	bs.Enqueue(agentlens.Event{SessionID: "s1", AgentID: "a1", EventType: "custom", Severity: "info"})
+	bs.Enqueue(agentlens.Event{SessionID: "s1", AgentID: "a1", EventType: agentlens.EventTypeCustom, Severity: agentlens.SeverityInfo})
 	if err := bs.Shutdown(ctx); err != nil {
 		log.Fatalf("batch shutdown failed: %v", err)
 	}
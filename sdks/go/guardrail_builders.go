@@ -0,0 +1,32 @@
+package agentlens
+
+import "time"
+
+// LatencyMetricKeyPath is the event metadata key path evaluated by guardrails
+// created with NewLatencyGuardrail. Agents must report per-call latency in
+// milliseconds under this path, e.g. metadata {"llm": {"latencyMs": 812}}.
+const LatencyMetricKeyPath = "llm.latencyMs"
+
+// NewCostThresholdGuardrail returns params for a cost_limit guardrail that
+// fires once spend reaches maxUsd. The server evaluates cost per session or
+// per UTC day: a zero window limits each session, and any non-zero window
+// uses the daily scope. The action defaults to pause_agent; change
+// ActionType/ActionConfig on the result before creating it to do otherwise.
+func NewCostThresholdGuardrail(name string, maxUsd float64, window time.Duration) *CreateGuardrailParams {
+	scope := "daily"
+	if window == 0 {
+		scope = "session"
+	}
+	p := &CreateGuardrailParams{Name: name, ActionType: "pause_agent", ActionConfig: map[string]any{}}
+	p.SetCondition(CostLimitCondition{MaxCostUsd: maxUsd, Scope: scope})
+	return p
+}
+
+// NewLatencyGuardrail returns params for a custom_metric guardrail that fires
+// when the latest latency reported under LatencyMetricKeyPath exceeds maxMs.
+// The action defaults to pause_agent, as with NewCostThresholdGuardrail.
+func NewLatencyGuardrail(name string, maxMs float64) *CreateGuardrailParams {
+	p := &CreateGuardrailParams{Name: name, ActionType: "pause_agent", ActionConfig: map[string]any{}}
+	p.SetCondition(ThresholdCondition{MetricKeyPath: LatencyMetricKeyPath, Operator: "gt", Value: maxMs})
+	return p
+}
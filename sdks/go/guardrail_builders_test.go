@@ -0,0 +1,30 @@
+package agentlens
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewCostThresholdGuardrail(t *testing.T) {
+	p := NewCostThresholdGuardrail("budget", 25, 24*time.Hour)
+	if p.ConditionType != "cost_limit" || p.ConditionConfig["maxCostUsd"] != 25.0 || p.ConditionConfig["scope"] != "daily" {
+		t.Errorf("unexpected params: %+v", p)
+	}
+	if p.ActionType == "" || p.ActionConfig == nil {
+		t.Errorf("expected a default action, got %+v", p)
+	}
+	if s := NewCostThresholdGuardrail("per-session", 1, 0).ConditionConfig["scope"]; s != "session" {
+		t.Errorf("expected session scope for zero window, got %v", s)
+	}
+}
+
+func TestNewLatencyGuardrail(t *testing.T) {
+	p := NewLatencyGuardrail("slow", 2000)
+	if p.ConditionType != "custom_metric" {
+		t.Errorf("unexpected condition type: %s", p.ConditionType)
+	}
+	cfg := p.ConditionConfig
+	if cfg["metricKeyPath"] != LatencyMetricKeyPath || cfg["operator"] != "gt" || cfg["value"] != 2000.0 {
+		t.Errorf("unexpected condition config: %+v", cfg)
+	}
+}
@@ -0,0 +1,194 @@
+package agentlens
+
+import "encoding/json"
+
+// GuardrailCondition is a typed condition config. Pass one to
+// CreateGuardrailParams.SetCondition instead of building ConditionConfig by hand.
+type GuardrailCondition interface {
+	// ConditionType returns the server condition type the config belongs to.
+	ConditionType() string
+	// ToMap returns the config in the generic form sent to the server.
+	ToMap() map[string]any
+}
+
+// GuardrailAction is a typed action config. Pass one to
+// CreateGuardrailParams.SetAction instead of building ActionConfig by hand.
+type GuardrailAction interface {
+	// ActionType returns the server action type the config belongs to.
+	ActionType() string
+	// ToMap returns the config in the generic form sent to the server.
+	ToMap() map[string]any
+}
+
+// ThresholdCondition compares the latest numeric value at MetricKeyPath in
+// event metadata against Value (condition type custom_metric).
+type ThresholdCondition struct {
+	// MetricKeyPath is a dot-separated path into event metadata, e.g. "llm.latencyMs".
+	MetricKeyPath string `json:"metricKeyPath,omitempty"`
+	// Operator is one of gt, gte, lt, lte, eq (server default gt).
+	Operator string  `json:"operator,omitempty"`
+	Value    float64 `json:"value"`
+	// WindowMinutes is how far back to look for events (server default 60).
+	WindowMinutes int `json:"windowMinutes,omitempty"`
+}
+
+// RateCondition fires when the percentage of error events within the window
+// reaches ThresholdPercent (condition type error_rate_threshold).
+type RateCondition struct {
+	ThresholdPercent float64 `json:"threshold"`
+	// WindowMinutes defaults to 5 on the server.
+	WindowMinutes int `json:"windowMinutes,omitempty"`
+}
+
+// PatternCondition matches content against a regular expression
+// (condition type content_regex).
+type PatternCondition struct {
+	Pattern string `json:"pattern"`
+	// Flags are JavaScript RegExp flags, e.g. "i".
+	Flags string `json:"flags,omitempty"`
+	// RedactionToken replaces matches when the action is redact.
+	RedactionToken string `json:"redactionToken,omitempty"`
+}
+
+// CostLimitCondition fires once spend reaches MaxCostUsd
+// (condition type cost_limit).
+type CostLimitCondition struct {
+	MaxCostUsd float64 `json:"maxCostUsd"`
+	// Scope is "session" or "daily" (UTC day, the server default).
+	Scope string `json:"scope,omitempty"`
+}
+
+// AlertAction records an alert without interrupting the agent (action type alert).
+type AlertAction struct {
+	Message string `json:"message,omitempty"`
+}
+
+// BlockAction rejects the offending content (action type block).
+type BlockAction struct {
+	Message string `json:"message,omitempty"`
+}
+
+// WebhookAction posts the trigger to URL (action type notify_webhook).
+type WebhookAction struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+func (ThresholdCondition) ConditionType() string { return "custom_metric" }
+func (RateCondition) ConditionType() string      { return "error_rate_threshold" }
+func (PatternCondition) ConditionType() string   { return "content_regex" }
+func (CostLimitCondition) ConditionType() string { return "cost_limit" }
+func (AlertAction) ActionType() string           { return "alert" }
+func (BlockAction) ActionType() string           { return "block" }
+func (WebhookAction) ActionType() string         { return "notify_webhook" }
+
+func (c ThresholdCondition) ToMap() map[string]any { return toConfigMap(c) }
+func (c RateCondition) ToMap() map[string]any      { return toConfigMap(c) }
+func (c PatternCondition) ToMap() map[string]any   { return toConfigMap(c) }
+func (c CostLimitCondition) ToMap() map[string]any { return toConfigMap(c) }
+func (a AlertAction) ToMap() map[string]any        { return toConfigMap(a) }
+func (a BlockAction) ToMap() map[string]any        { return toConfigMap(a) }
+func (a WebhookAction) ToMap() map[string]any      { return toConfigMap(a) }
+
+// toConfigMap converts a typed config to the generic map form.
+func toConfigMap(v any) map[string]any {
+	m := map[string]any{}
+	data, err := json.Marshal(v)
+	if err == nil {
+		_ = json.Unmarshal(data, &m)
+	}
+	return m
+}
+
+// fromConfigMap decodes a generic config map into a typed config. Unknown
+// keys are ignored; values of the wrong JSON type fail the decode.
+func fromConfigMap(m map[string]any, v any) bool {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// SetCondition sets ConditionType and ConditionConfig from a typed condition.
+func (p *CreateGuardrailParams) SetCondition(c GuardrailCondition) {
+	p.ConditionType = c.ConditionType()
+	p.ConditionConfig = c.ToMap()
+}
+
+// SetAction sets ActionType and ActionConfig from a typed action.
+func (p *CreateGuardrailParams) SetAction(a GuardrailAction) {
+	p.ActionType = a.ActionType()
+	p.ActionConfig = a.ToMap()
+}
+
+// ThresholdCondition returns the rule's condition config if it is a custom_metric condition.
+func (r *GuardrailRule) ThresholdCondition() (*ThresholdCondition, bool) {
+	var c ThresholdCondition
+	if !r.condition(&c) {
+		return nil, false
+	}
+	return &c, true
+}
+
+// RateCondition returns the rule's condition config if it is an error_rate_threshold condition.
+func (r *GuardrailRule) RateCondition() (*RateCondition, bool) {
+	var c RateCondition
+	if !r.condition(&c) {
+		return nil, false
+	}
+	return &c, true
+}
+
+// PatternCondition returns the rule's condition config if it is a content_regex condition.
+func (r *GuardrailRule) PatternCondition() (*PatternCondition, bool) {
+	var c PatternCondition
+	if !r.condition(&c) {
+		return nil, false
+	}
+	return &c, true
+}
+
+// CostLimitCondition returns the rule's condition config if it is a cost_limit condition.
+func (r *GuardrailRule) CostLimitCondition() (*CostLimitCondition, bool) {
+	var c CostLimitCondition
+	if !r.condition(&c) {
+		return nil, false
+	}
+	return &c, true
+}
+
+// AlertAction returns the rule's action config if it is an alert action.
+func (r *GuardrailRule) AlertAction() (*AlertAction, bool) {
+	var a AlertAction
+	if !r.action(&a) {
+		return nil, false
+	}
+	return &a, true
+}
+
+// BlockAction returns the rule's action config if it is a block action.
+func (r *GuardrailRule) BlockAction() (*BlockAction, bool) {
+	var a BlockAction
+	if !r.action(&a) {
+		return nil, false
+	}
+	return &a, true
+}
+
+// WebhookAction returns the rule's action config if it is a notify_webhook action.
+func (r *GuardrailRule) WebhookAction() (*WebhookAction, bool) {
+	var a WebhookAction
+	if !r.action(&a) {
+		return nil, false
+	}
+	return &a, true
+}
+
+func (r *GuardrailRule) condition(c GuardrailCondition) bool {
+	return r.ConditionType == c.ConditionType() && fromConfigMap(r.ConditionConfig, c)
+}
+
+func (r *GuardrailRule) action(a GuardrailAction) bool {
+	return r.ActionType == a.ActionType() && fromConfigMap(r.ActionConfig, a)
+}
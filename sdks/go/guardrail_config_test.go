@@ -0,0 +1,46 @@
+package agentlens
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGuardrailTypedConfigRoundTrip(t *testing.T) {
+	var p CreateGuardrailParams
+	p.SetCondition(RateCondition{ThresholdPercent: 25, WindowMinutes: 10})
+	p.SetAction(WebhookAction{URL: "https://example.com/hook", Headers: map[string]string{"X-Token": "t"}})
+	if p.ConditionType != "error_rate_threshold" || p.ConditionConfig["threshold"] != 25.0 || p.ConditionConfig["windowMinutes"] != 10.0 {
+		t.Errorf("unexpected condition: %s %+v", p.ConditionType, p.ConditionConfig)
+	}
+	if p.ActionType != "notify_webhook" || p.ActionConfig["url"] != "https://example.com/hook" {
+		t.Errorf("unexpected action: %s %+v", p.ActionType, p.ActionConfig)
+	}
+
+	// Round-trip through the wire format, as a rule fetched from the server.
+	data, _ := json.Marshal(p)
+	var rule GuardrailRule
+	if err := json.Unmarshal(data, &rule); err != nil {
+		t.Fatal(err)
+	}
+	rc, ok := rule.RateCondition()
+	if !ok || rc.ThresholdPercent != 25 || rc.WindowMinutes != 10 {
+		t.Errorf("RateCondition() = %+v, %v", rc, ok)
+	}
+	wa, ok := rule.WebhookAction()
+	if !ok || wa.URL != "https://example.com/hook" || wa.Headers["X-Token"] != "t" {
+		t.Errorf("WebhookAction() = %+v, %v", wa, ok)
+	}
+	if _, ok := rule.ThresholdCondition(); ok {
+		t.Error("expected ThresholdCondition() to report false for an error_rate_threshold rule")
+	}
+	if _, ok := rule.BlockAction(); ok {
+		t.Error("expected BlockAction() to report false for a notify_webhook rule")
+	}
+}
+
+func TestGuardrailTypedConfigWrongValueType(t *testing.T) {
+	rule := GuardrailRule{ConditionType: "content_regex", ConditionConfig: map[string]any{"pattern": 42}}
+	if _, ok := rule.PatternCondition(); ok {
+		t.Error("expected a non-string pattern to fail decoding")
+	}
+}
@@ -0,0 +1,181 @@
+package agentlens
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// guardrailSchemaJSON describes the config keys of each condition and action
+// type known to this SDK version, mirroring the server's config schemas.
+//
+//go:embed guardrail_schema.json
+var guardrailSchemaJSON []byte
+
+// GuardrailField describes one key of a guardrail condition or action config.
+type GuardrailField struct {
+	// Type is the JSON type of the value: "string", "number", "integer",
+	// "boolean", "array" or "object".
+	Type     string `json:"type"`
+	Required bool   `json:"required,omitempty"`
+	// Enum, if set, lists the allowed string values.
+	Enum []string `json:"enum,omitempty"`
+}
+
+var guardrailSchemas struct {
+	sync.RWMutex
+	Conditions map[string]map[string]GuardrailField `json:"conditions"`
+	Actions    map[string]map[string]GuardrailField `json:"actions"`
+}
+
+func init() {
+	if err := json.Unmarshal(guardrailSchemaJSON, &guardrailSchemas); err != nil {
+		panic("agentlens: invalid embedded guardrail schema: " + err.Error())
+	}
+}
+
+// RegisterGuardrailConditionSchema adds or replaces the schema used by
+// client-side validation for conditionType, e.g. for a condition type added
+// by a newer server. fields maps each config key to its description.
+func RegisterGuardrailConditionSchema(conditionType string, fields map[string]GuardrailField) {
+	guardrailSchemas.Lock()
+	defer guardrailSchemas.Unlock()
+	guardrailSchemas.Conditions[conditionType] = fields
+}
+
+// RegisterGuardrailActionSchema is RegisterGuardrailConditionSchema for
+// action types.
+func RegisterGuardrailActionSchema(actionType string, fields map[string]GuardrailField) {
+	guardrailSchemas.Lock()
+	defer guardrailSchemas.Unlock()
+	guardrailSchemas.Actions[actionType] = fields
+}
+
+// Validate checks ConditionConfig and ActionConfig against the schema for
+// ConditionType and ActionType: required keys must be present, and values
+// must have the right JSON type and, for enumerations, an allowed value.
+// Types without a schema, and keys the schema doesn't mention, are not
+// checked. Returns a *ValidationError naming the offending key, e.g.
+// "conditionConfig.maxCostUsd". With WithClientValidation, CreateGuardrail
+// calls it before sending.
+func (p *CreateGuardrailParams) Validate() error {
+	if err := validateGuardrailConfig("conditionConfig", p.ConditionType, p.ConditionConfig, true); err != nil {
+		return err
+	}
+	return validateGuardrailConfig("actionConfig", p.ActionType, p.ActionConfig, false)
+}
+
+// Validate is CreateGuardrailParams.Validate for the configs being updated.
+// A config is checked only if its type is set as well.
+func (p *UpdateGuardrailParams) Validate() error {
+	if p.ConditionType != nil && p.ConditionConfig != nil {
+		if err := validateGuardrailConfig("conditionConfig", *p.ConditionType, p.ConditionConfig, true); err != nil {
+			return err
+		}
+	}
+	if p.ActionType != nil && p.ActionConfig != nil {
+		return validateGuardrailConfig("actionConfig", *p.ActionType, p.ActionConfig, false)
+	}
+	return nil
+}
+
+func validateGuardrailConfig(prefix, typ string, config map[string]any, condition bool) error {
+	guardrailSchemas.RLock()
+	schema, ok := guardrailSchemas.Actions[typ]
+	if condition {
+		schema, ok = guardrailSchemas.Conditions[typ]
+	}
+	guardrailSchemas.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	// Normalize Go values (ints, typed slices and maps) to their JSON forms.
+	var cfg map[string]any
+	if err := remarshal(config, &cfg); err != nil {
+		return newFieldValidationError(prefix, err.Error())
+	}
+	keys := make([]string, 0, len(schema))
+	for k := range schema {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f := schema[k]
+		v, present := cfg[k]
+		if !present || v == nil {
+			if f.Required {
+				return newFieldValidationError(prefix+"."+k, fmt.Sprintf("is required for %s", typ))
+			}
+			continue
+		}
+		if msg := f.check(v); msg != "" {
+			return newFieldValidationError(prefix+"."+k, msg)
+		}
+	}
+	return nil
+}
+
+// check returns why v, a decoded JSON value, does not match f, or "".
+func (f GuardrailField) check(v any) string {
+	ok := true
+	switch f.Type {
+	case "string":
+		s, isString := v.(string)
+		ok = isString
+		if ok && len(f.Enum) > 0 && !contains(f.Enum, s) {
+			return fmt.Sprintf("must be one of %s, got %q", strings.Join(f.Enum, ", "), s)
+		}
+	case "number":
+		_, ok = v.(float64)
+	case "integer":
+		n, isNumber := v.(float64)
+		ok = isNumber && n == math.Trunc(n)
+	case "boolean":
+		_, ok = v.(bool)
+	case "array":
+		_, ok = v.([]any)
+	case "object":
+		_, ok = v.(map[string]any)
+	}
+	if !ok {
+		return fmt.Sprintf("must be %s %s, got %s", article(f.Type), f.Type, jsonType(v))
+	}
+	return ""
+}
+
+// jsonType names the JSON type of a decoded JSON value.
+func jsonType(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func article(word string) string {
+	if strings.ContainsRune("aeiou", rune(word[0])) {
+		return "an"
+	}
+	return "a"
+}
@@ -0,0 +1,78 @@
+package agentlens
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGuardrailConfigValidation(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"id":"g1"}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key", WithClientValidation())
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params CreateGuardrailParams
+		field  string
+	}{
+		{"missing required key", CreateGuardrailParams{
+			ConditionType: "cost_limit", ConditionConfig: map[string]any{"maxCost": 5},
+			ActionType: "alert", ActionConfig: map[string]any{},
+		}, "conditionConfig.maxCostUsd"},
+		{"wrong type", CreateGuardrailParams{
+			ConditionType: "error_rate_threshold", ConditionConfig: map[string]any{"threshold": "25"},
+			ActionType: "alert", ActionConfig: map[string]any{},
+		}, "conditionConfig.threshold"},
+		{"non-integer", CreateGuardrailParams{
+			ConditionType: "error_rate_threshold", ConditionConfig: map[string]any{"threshold": 25, "windowMinutes": 2.5},
+			ActionType: "alert", ActionConfig: map[string]any{},
+		}, "conditionConfig.windowMinutes"},
+		{"bad enum", CreateGuardrailParams{
+			ConditionType: "cost_limit", ConditionConfig: map[string]any{"maxCostUsd": 5, "scope": "weekly"},
+			ActionType: "alert", ActionConfig: map[string]any{},
+		}, "conditionConfig.scope"},
+		{"action", CreateGuardrailParams{
+			ConditionType: "cost_limit", ConditionConfig: map[string]any{"maxCostUsd": 5},
+			ActionType: "notify_webhook", ActionConfig: map[string]any{"headers": map[string]string{}},
+		}, "actionConfig.url"},
+	}
+	for _, tt := range tests {
+		_, err := c.CreateGuardrail(ctx, &tt.params)
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("%s: expected ValidationError, got %v", tt.name, err)
+			continue
+		}
+		if fe, _ := ve.Details.([]FieldError); len(fe) != 1 || fe[0].Field != tt.field {
+			t.Errorf("%s: expected ValidationError on %s, got %v", tt.name, tt.field, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("expected invalid configs to be rejected locally, got %d requests", requests)
+	}
+
+	// Typed configs, unknown types and registered schemas.
+	var p CreateGuardrailParams
+	p.SetCondition(CostLimitCondition{MaxCostUsd: 10, Scope: "session"})
+	p.SetAction(WebhookAction{URL: "https://example.com/hook"})
+	if _, err := c.CreateGuardrail(ctx, &p); err != nil {
+		t.Errorf("valid typed config rejected: %v", err)
+	}
+	unknown := &CreateGuardrailParams{ConditionType: "future_condition", ConditionConfig: map[string]any{"x": 1}, ActionType: "block", ActionConfig: map[string]any{}}
+	if err := unknown.Validate(); err != nil {
+		t.Errorf("unknown condition types should not be checked: %v", err)
+	}
+	RegisterGuardrailConditionSchema("future_condition", map[string]GuardrailField{"limit": {Type: "number", Required: true}})
+	defer RegisterGuardrailConditionSchema("future_condition", nil)
+	if err := unknown.Validate(); err == nil {
+		t.Error("expected the registered schema to require limit")
+	}
+}
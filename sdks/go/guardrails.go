@@ -0,0 +1,311 @@
+package agentlens
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ExportGuardrails returns all guardrail rules, optionally filtered by agent,
+// in a form suitable for storing in version control and re-importing.
+func (c *Client) ExportGuardrails(ctx context.Context, agentID *string) ([]GuardrailRule, error) {
+	result, err := c.ListGuardrails(ctx, &GuardrailListOpts{AgentID: agentID})
+	if err != nil {
+		return nil, err
+	}
+	return result.Rules, nil
+}
+
+// ImportGuardrails creates each rule, matching existing rules by Name. Rules
+// whose name already exists are skipped, or updated if opts.Upsert is set.
+// A failure on one rule is recorded in the result's Errors and does not
+// abort the rest of the import; only a failure to list existing rules is
+// returned as an error.
+func (c *Client) ImportGuardrails(ctx context.Context, rules []CreateGuardrailParams, opts *GuardrailImportOpts) (*GuardrailImportResult, error) {
+	var existing GuardrailRuleListResult
+	if err := c.do(ctx, http.MethodGet, "/api/guardrails", nil, &existing, false); err != nil {
+		return &GuardrailImportResult{}, c.failOpen(err, nil)
+	}
+	byName := make(map[string]string, len(existing.Rules))
+	for _, r := range existing.Rules {
+		byName[r.Name] = r.ID
+	}
+
+	result := &GuardrailImportResult{}
+	for i := range rules {
+		rule := &rules[i]
+		id, exists := byName[rule.Name]
+		var err error
+		switch {
+		case exists && (opts == nil || !opts.Upsert):
+			result.Skipped++
+			continue
+		case exists:
+			err = c.do(ctx, http.MethodPut, "/api/guardrails/"+url.PathEscape(id), updateFromCreate(rule), nil, false)
+			if err == nil {
+				result.Updated++
+			}
+		default:
+			var created GuardrailRule
+			err = c.do(ctx, http.MethodPost, "/api/guardrails", rule, &created, false)
+			if err == nil {
+				result.Created++
+				byName[rule.Name] = created.ID
+			}
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, GuardrailImportError{Index: i, Name: rule.Name, Err: err})
+		}
+	}
+	return result, nil
+}
+
+// updateFromCreate converts create params into a full update.
+func updateFromCreate(p *CreateGuardrailParams) *UpdateGuardrailParams {
+	return &UpdateGuardrailParams{
+		Name:            &p.Name,
+		Description:     p.Description,
+		ConditionType:   &p.ConditionType,
+		ConditionConfig: p.ConditionConfig,
+		ActionType:      &p.ActionType,
+		ActionConfig:    p.ActionConfig,
+		AgentID:         p.AgentID,
+		Enabled:         p.Enabled,
+		DryRun:          p.DryRun,
+		CooldownMinutes: p.CooldownMinutes,
+	}
+}
+
+// GuardrailHistoryIterator pages through guardrail trigger history.
+type GuardrailHistoryIterator struct {
+	c      *Client
+	ctx    context.Context
+	opts   GuardrailHistoryOpts
+	offset int
+	total  int // Total from the first page; -1 until fetched
+	pos    int // absolute index of the next trigger
+	page   []GuardrailTriggerHistory
+	idx    int
+	cur    GuardrailTriggerHistory
+	err    error
+	done   bool
+}
+
+// GuardrailHistoryIterator returns an iterator over all triggers matching
+// opts, advancing Offset automatically. opts.Limit sets the page size
+// (default 100). Iteration stops at the Total reported by the first page, so
+// triggers appended during iteration cannot make it loop forever.
+//
+//	it := client.GuardrailHistoryIterator(ctx, nil)
+//	for it.Next() {
+//	    t := it.Trigger()
+//	}
+//	if err := it.Err(); err != nil { ... }
+func (c *Client) GuardrailHistoryIterator(ctx context.Context, opts *GuardrailHistoryOpts) *GuardrailHistoryIterator {
+	it := &GuardrailHistoryIterator{c: c, ctx: ctx, total: -1}
+	if opts != nil {
+		it.opts = *opts
+	}
+	if it.opts.Offset != nil {
+		it.offset = *it.opts.Offset
+		it.pos = it.offset
+	}
+	if it.opts.Limit == nil {
+		limit := 100
+		it.opts.Limit = &limit
+	}
+	return it
+}
+
+// Next advances to the next trigger, fetching a new page when needed.
+// It returns false when iteration is complete or an error occurred.
+func (it *GuardrailHistoryIterator) Next() bool {
+	if it.err != nil || (it.total >= 0 && it.pos >= it.total) {
+		return false
+	}
+	if it.idx >= len(it.page) {
+		if it.done || !it.fetch() {
+			return false
+		}
+	}
+	it.cur = it.page[it.idx]
+	it.idx++
+	it.pos++
+	return true
+}
+
+func (it *GuardrailHistoryIterator) fetch() bool {
+	offset := it.offset
+	opts := it.opts
+	opts.Offset = &offset
+	p := url.Values{}
+	addQueryParam(&p, "ruleId", opts.RuleID)
+	addQueryInt(&p, "limit", opts.Limit)
+	addQueryInt(&p, "offset", opts.Offset)
+	var result GuardrailTriggerHistoryResult
+	if err := it.c.do(it.ctx, http.MethodGet, "/api/guardrails/history?"+p.Encode(), nil, &result, false); err != nil {
+		it.err = it.c.failOpen(err, nil)
+		it.done = true
+		return false
+	}
+	if it.total < 0 {
+		it.total = result.Total
+	}
+	it.page = result.Triggers
+	it.idx = 0
+	it.offset += len(result.Triggers)
+	if len(result.Triggers) == 0 || it.offset >= it.total {
+		it.done = true
+	}
+	return len(it.page) > 0
+}
+
+// Trigger returns the current trigger. Only valid after Next returns true.
+func (it *GuardrailHistoryIterator) Trigger() GuardrailTriggerHistory { return it.cur }
+
+// Err returns the error that stopped iteration, if any.
+func (it *GuardrailHistoryIterator) Err() error { return it.err }
+
+// guardrailHistoryPageSize is the largest page the history endpoint returns.
+const guardrailHistoryPageSize = 200
+
+// guardrailTrigger is a trigger history record as the server stores it.
+type guardrailTrigger struct {
+	ID           string         `json:"id"`
+	RuleID       string         `json:"ruleId"`
+	TriggeredAt  string         `json:"triggeredAt"`
+	ActionResult string         `json:"actionResult"`
+	Metadata     map[string]any `json:"metadata"`
+}
+
+// GetGuardrailStats gets per-rule trigger counts and action breakdowns over a
+// time window. The server has no stats endpoint, so this pages through the
+// trigger history, newest first, and aggregates it locally; paging stops at
+// the first trigger older than opts.From, so without From the whole history
+// is read. AgentID matches the agent recorded with each trigger. Rules are
+// ordered by most recent trigger, ActionCounts is keyed by the recorded
+// action result (e.g. "pause_agent" or "dry_run"), and rule names come from
+// ListGuardrails.
+func (c *Client) GetGuardrailStats(ctx context.Context, opts *GuardrailStatsOpts) (*GuardrailStatsResult, error) {
+	var result GuardrailStatsResult
+	err := c.guardrailStats(ctx, opts, &result)
+	return &result, c.failOpen(err, &result)
+}
+
+func (c *Client) guardrailStats(ctx context.Context, opts *GuardrailStatsOpts, result *GuardrailStatsResult) error {
+	var o GuardrailStatsOpts
+	if opts != nil {
+		o = *opts
+	}
+	var from, to time.Time
+	if o.From != nil {
+		t, err := time.Parse(time.RFC3339Nano, *o.From)
+		if err != nil {
+			return fmt.Errorf("agentlens: invalid From: %w", err)
+		}
+		from = t
+	}
+	if o.To != nil {
+		t, err := time.Parse(time.RFC3339Nano, *o.To)
+		if err != nil {
+			return fmt.Errorf("agentlens: invalid To: %w", err)
+		}
+		to = t
+	}
+
+	var rules GuardrailRuleListResult
+	if err := c.do(ctx, http.MethodGet, "/api/guardrails", nil, &rules, false); err != nil {
+		return err
+	}
+	names := make(map[string]string, len(rules.Rules))
+	for _, r := range rules.Rules {
+		names[r.ID] = r.Name
+	}
+
+	byRule := map[string]int{} // rule ID -> index in result.Rules
+	result.From, result.To = o.From, o.To
+	for offset := 0; ; offset += guardrailHistoryPageSize {
+		var page struct {
+			Triggers []guardrailTrigger `json:"triggers"`
+			Total    int                `json:"total"`
+		}
+		path := fmt.Sprintf("/api/guardrails/history?limit=%d&offset=%d", guardrailHistoryPageSize, offset)
+		if err := c.do(ctx, http.MethodGet, path, nil, &page, false); err != nil {
+			return err
+		}
+		for _, tr := range page.Triggers {
+			ts, err := time.Parse(time.RFC3339Nano, tr.TriggeredAt)
+			if err == nil && !from.IsZero() && ts.Before(from) {
+				return nil
+			}
+			if err == nil && !to.IsZero() && ts.After(to) {
+				continue
+			}
+			agentID, _ := tr.Metadata["agentId"].(string)
+			if o.AgentID != nil && agentID != *o.AgentID {
+				continue
+			}
+			i, ok := byRule[tr.RuleID]
+			if !ok {
+				i = len(result.Rules)
+				byRule[tr.RuleID] = i
+				last := tr.toHistory(names[tr.RuleID])
+				result.Rules = append(result.Rules, GuardrailRuleStats{
+					RuleID:        tr.RuleID,
+					RuleName:      names[tr.RuleID],
+					LastTriggered: &last.Timestamp,
+					ActionCounts:  map[string]int{},
+					LastTrigger:   &last,
+				})
+			}
+			result.Rules[i].TriggerCount++
+			result.Rules[i].ActionCounts[tr.ActionResult]++
+			result.TotalTriggers++
+		}
+		if len(page.Triggers) < guardrailHistoryPageSize || offset+len(page.Triggers) >= page.Total {
+			return nil
+		}
+	}
+}
+
+// toHistory converts a stored trigger to a GuardrailTriggerHistory.
+func (tr guardrailTrigger) toHistory(ruleName string) GuardrailTriggerHistory {
+	h := GuardrailTriggerHistory{
+		ID:        tr.ID,
+		RuleID:    tr.RuleID,
+		RuleName:  ruleName,
+		Action:    tr.ActionResult,
+		Details:   tr.Metadata,
+		Timestamp: tr.TriggeredAt,
+	}
+	for key, dst := range map[string]**string{"eventId": &h.EventID, "sessionId": &h.SessionID, "agentId": &h.AgentID} {
+		if v, ok := tr.Metadata[key].(string); ok {
+			*dst = &v
+		}
+	}
+	return h
+}
+
+// InCooldown reports whether the rule was triggered less than its
+// CooldownMinutes before now, so the server will skip it until the cooldown
+// ends. A rule that has never triggered, or has no cooldown, is not in
+// cooldown.
+func (s *GuardrailStatusResult) InCooldown(now time.Time) bool {
+	if s.Rule.CooldownMinutes == nil || *s.Rule.CooldownMinutes <= 0 || s.State == nil {
+		return false
+	}
+	last := s.State.LastTriggeredAt
+	if last == nil {
+		last = s.State.LastTriggered
+	}
+	if last == nil {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339Nano, *last)
+	if err != nil {
+		return false
+	}
+	return now.Sub(t) < time.Duration(*s.Rule.CooldownMinutes)*time.Minute
+}
@@ -0,0 +1,160 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestImportGuardrails(t *testing.T) {
+	var created, updated []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/guardrails":
+			json.NewEncoder(w).Encode(GuardrailRuleListResult{Rules: []GuardrailRule{{ID: "g1", Name: "existing"}}})
+		case r.Method == "POST":
+			var p CreateGuardrailParams
+			json.NewDecoder(r.Body).Decode(&p)
+			if p.Name == "broken" {
+				w.WriteHeader(400)
+				w.Write([]byte(`{"error":"bad config"}`))
+				return
+			}
+			created = append(created, p.Name)
+			json.NewEncoder(w).Encode(GuardrailRule{ID: "new", Name: p.Name})
+		case r.Method == "PUT" && r.URL.Path == "/api/guardrails/g1":
+			updated = append(updated, "g1")
+			json.NewEncoder(w).Encode(GuardrailRule{ID: "g1"})
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	rules := []CreateGuardrailParams{{Name: "existing"}, {Name: "broken"}, {Name: "fresh"}}
+
+	res, err := c.ImportGuardrails(context.Background(), rules, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Created != 1 || res.Skipped != 1 || res.Updated != 0 || len(res.Errors) != 1 || res.Errors[0].Index != 1 {
+		t.Errorf("unexpected result without upsert: %+v", res)
+	}
+
+	res, err = c.ImportGuardrails(context.Background(), rules, &GuardrailImportOpts{Upsert: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Updated != 1 || res.Skipped != 0 || len(updated) != 1 {
+		t.Errorf("unexpected result with upsert: %+v", res)
+	}
+}
+
+func TestExportGuardrails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("agentId") != "a1" {
+			t.Errorf("expected agentId filter, got %s", r.URL)
+		}
+		json.NewEncoder(w).Encode(GuardrailRuleListResult{Rules: []GuardrailRule{{ID: "g1"}, {ID: "g2"}}})
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+	agentID := "a1"
+	rules, err := c.ExportGuardrails(context.Background(), &agentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Errorf("expected 2 rules, got %d", len(rules))
+	}
+}
+
+func TestGuardrailHistoryIterator(t *testing.T) {
+	var requests int
+	total := 5
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		offset := 0
+		fmt.Sscan(r.URL.Query().Get("offset"), &offset)
+		if r.URL.Query().Get("limit") != "2" {
+			t.Errorf("expected limit=2, got %s", r.URL.Query().Get("limit"))
+		}
+		// Simulate new triggers arriving after the first page.
+		reported := total
+		total += 3
+		var triggers []GuardrailTriggerHistory
+		for i := offset; i < offset+2 && i < total; i++ {
+			triggers = append(triggers, GuardrailTriggerHistory{ID: fmt.Sprintf("t%d", i)})
+		}
+		json.NewEncoder(w).Encode(GuardrailTriggerHistoryResult{Triggers: triggers, Total: reported})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key")
+	limit := 2
+	it := c.GuardrailHistoryIterator(context.Background(), &GuardrailHistoryOpts{Limit: &limit})
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Trigger().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 5 || ids[0] != "t0" || ids[4] != "t4" {
+		t.Errorf("expected t0..t4, got %v", ids)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 page requests, got %d", requests)
+	}
+}
+
+func TestGuardrailHistoryIteratorError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(401)
+		w.Write([]byte(`{"error":"unauthorized"}`))
+	}))
+	defer srv.Close()
+
+	it := NewClient(srv.URL, "key").GuardrailHistoryIterator(context.Background(), nil)
+	if it.Next() {
+		t.Fatal("expected no results")
+	}
+	if it.Err() == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGuardrailInCooldown(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *string {
+		s := now.Add(-d).Format(time.RFC3339Nano)
+		return &s
+	}
+	cooldown := 15
+	zero := 0
+	tests := []struct {
+		name     string
+		cooldown *int
+		state    *GuardrailState
+		want     bool
+	}{
+		{"recent trigger", &cooldown, &GuardrailState{LastTriggeredAt: ago(5 * time.Minute)}, true},
+		{"cooldown over", &cooldown, &GuardrailState{LastTriggeredAt: ago(20 * time.Minute)}, false},
+		{"legacy field", &cooldown, &GuardrailState{LastTriggered: ago(time.Minute)}, true},
+		{"never triggered", &cooldown, &GuardrailState{}, false},
+		{"no state", &cooldown, nil, false},
+		{"no cooldown", nil, &GuardrailState{LastTriggeredAt: ago(time.Minute)}, false},
+		{"zero cooldown", &zero, &GuardrailState{LastTriggeredAt: ago(time.Minute)}, false},
+	}
+	for _, tt := range tests {
+		s := &GuardrailStatusResult{Rule: GuardrailRule{CooldownMinutes: tt.cooldown}, State: tt.state}
+		if got := s.InCooldown(now); got != tt.want {
+			t.Errorf("%s: InCooldown = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package agentlens
+
+import "encoding/json"
+
+// HealthComponents are the per-dimension sub-scores of an agent's health,
+// each 0-100 like HealthScore.OverallScore. A dimension the server did not
+// report is zero.
+type HealthComponents struct {
+	ErrorRate      float64 `json:"errorRate"`
+	CostEfficiency float64 `json:"costEfficiency"`
+	ToolSuccess    float64 `json:"toolSuccess"`
+	Latency        float64 `json:"latency"`
+	CompletionRate float64 `json:"completionRate"`
+}
+
+// set stores score under the dimension called name, reporting whether name
+// is known.
+func (hc *HealthComponents) set(name string, score float64) bool {
+	switch name {
+	case "error_rate":
+		hc.ErrorRate = score
+	case "cost_efficiency":
+		hc.CostEfficiency = score
+	case "tool_success":
+		hc.ToolSuccess = score
+	case "latency":
+		hc.Latency = score
+	case "completion_rate":
+		hc.CompletionRate = score
+	default:
+		return false
+	}
+	return true
+}
+
+// Components returns the score's per-dimension sub-scores, from Dimensions
+// or, for older servers, RawComponents. ok is false if the
+// response carried neither.
+func (h *HealthScore) Components() (hc HealthComponents, ok bool) {
+	for _, d := range h.Dimensions {
+		if hc.set(d.Name, d.Score) {
+			ok = true
+		}
+	}
+	if ok {
+		return hc, true
+	}
+	return legacyComponents(h.RawComponents)
+}
+
+// Components returns the snapshot's per-dimension sub-scores, from the
+// per-dimension score fields of current servers or the RawComponents of
+// older ones. ok is false if the response carried neither.
+func (s *HealthSnapshot) Components() (HealthComponents, bool) {
+	if s.scores != nil {
+		return *s.scores, true
+	}
+	return legacyComponents(s.RawComponents)
+}
+
+// legacyComponents decodes an untyped components object.
+func legacyComponents(v any) (HealthComponents, bool) {
+	var hc HealthComponents
+	if _, isMap := v.(map[string]any); !isMap || remarshal(v, &hc) != nil {
+		return HealthComponents{}, false
+	}
+	return hc, true
+}
+
+func (s *HealthSnapshot) UnmarshalJSON(data []byte) error {
+	type plain HealthSnapshot
+	var wire struct {
+		*plain
+		ErrorRateScore      *float64 `json:"errorRateScore"`
+		CostEfficiencyScore *float64 `json:"costEfficiencyScore"`
+		ToolSuccessScore    *float64 `json:"toolSuccessScore"`
+		LatencyScore        *float64 `json:"latencyScore"`
+		CompletionRateScore *float64 `json:"completionRateScore"`
+	}
+	wire.plain = (*plain)(s)
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	s.scores = nil
+	for name, v := range map[string]*float64{
+		"error_rate":      wire.ErrorRateScore,
+		"cost_efficiency": wire.CostEfficiencyScore,
+		"tool_success":    wire.ToolSuccessScore,
+		"latency":         wire.LatencyScore,
+		"completion_rate": wire.CompletionRateScore,
+	} {
+		if v == nil {
+			continue
+		}
+		if s.scores == nil {
+			s.scores = &HealthComponents{}
+		}
+		s.scores.set(name, *v)
+	}
+	return nil
+}
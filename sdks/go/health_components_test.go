@@ -0,0 +1,40 @@
+package agentlens
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHealthComponents(t *testing.T) {
+	var score HealthScore
+	json.Unmarshal([]byte(`{"agentId":"a1","overallScore":82,"dimensions":[
+		{"name":"error_rate","score":90,"weight":0.3,"rawValue":0.1},
+		{"name":"latency","score":60,"weight":0.15,"rawValue":2300}]}`), &score)
+	hc, ok := score.Components()
+	if !ok || hc.ErrorRate != 90 || hc.Latency != 60 || hc.ToolSuccess != 0 {
+		t.Errorf("dimensions: got %+v, %v", hc, ok)
+	}
+
+	var legacy HealthScore
+	json.Unmarshal([]byte(`{"agentId":"a1","score":0.8,"components":{"errorRate":70,"costEfficiency":50}}`), &legacy)
+	if hc, ok := legacy.Components(); !ok || hc.ErrorRate != 70 || hc.CostEfficiency != 50 {
+		t.Errorf("legacy components: got %+v, %v", hc, ok)
+	}
+	if _, ok := (&HealthScore{RawComponents: "n/a"}).Components(); ok {
+		t.Error("expected ok=false for an unrecognised components value")
+	}
+
+	var history []HealthSnapshot
+	json.Unmarshal([]byte(`[
+		{"agentId":"a1","date":"2026-01-01","overallScore":75,"errorRateScore":80,"toolSuccessScore":95,"completionRateScore":100},
+		{"agentId":"a1","timestamp":"2026-01-02T00:00:00Z","score":0.7}]`), &history)
+	if len(history) != 2 || history[0].OverallScore != 75 {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	if hc, ok := history[0].Components(); !ok || hc.ErrorRate != 80 || hc.ToolSuccess != 95 || hc.CompletionRate != 100 {
+		t.Errorf("snapshot scores: got %+v, %v", hc, ok)
+	}
+	if _, ok := history[1].Components(); ok {
+		t.Error("expected ok=false for a snapshot without components")
+	}
+}
@@ -0,0 +1,82 @@
+package agentlens
+
+import (
+	"sort"
+	"time"
+)
+
+// HealthHistory is a series of health snapshots, as returned by
+// GetHealthHistory. Convert the result to use its methods:
+//
+//	buckets := agentlens.HealthHistory(snaps).Aggregate(7 * 24 * time.Hour)
+type HealthHistory []HealthSnapshot
+
+// HealthBucket summarizes the health snapshots that fall in one time bucket.
+type HealthBucket struct {
+	// Start is the start of the bucket, a multiple of the bucket size
+	// since the Unix epoch, and End the start of the next bucket.
+	Start time.Time
+	End   time.Time
+	// Count is the number of snapshots in the bucket.
+	Count int
+	Avg   float64
+	Min   float64
+	Max   float64
+}
+
+// Aggregate groups the snapshots into buckets of the given size and
+// returns the average, minimum and maximum score of each, oldest first.
+// Only buckets holding at least one snapshot are returned, so gaps in the
+// history stay gaps. A snapshot's score is OverallScore on current servers
+// and Score on older ones; its time is Timestamp, or Date if Timestamp is
+// empty. Snapshots without a parseable time are skipped. Aggregate returns
+// nil if bucket is not positive.
+func (h HealthHistory) Aggregate(bucket time.Duration) []HealthBucket {
+	if bucket <= 0 {
+		return nil
+	}
+	byStart := map[int64]*HealthBucket{}
+	for _, s := range h {
+		t, ok := s.takenAt()
+		if !ok {
+			continue
+		}
+		score := s.overallScore()
+		start := t.Truncate(bucket)
+		b := byStart[start.UnixNano()]
+		if b == nil {
+			b = &HealthBucket{Start: start, End: start.Add(bucket), Min: score, Max: score}
+			byStart[start.UnixNano()] = b
+		}
+		// Avg holds the running sum until all snapshots are added.
+		b.Avg += score
+		b.Count++
+		b.Min = min(b.Min, score)
+		b.Max = max(b.Max, score)
+	}
+	buckets := make([]HealthBucket, 0, len(byStart))
+	for _, b := range byStart {
+		b.Avg /= float64(b.Count)
+		buckets = append(buckets, *b)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Start.Before(buckets[j].Start) })
+	return buckets
+}
+
+// takenAt returns when the snapshot was taken.
+func (s *HealthSnapshot) takenAt() (time.Time, bool) {
+	if s.Timestamp != "" {
+		t, err := time.Parse(time.RFC3339Nano, s.Timestamp)
+		return t.UTC(), err == nil
+	}
+	t, err := time.Parse(time.DateOnly, s.Date)
+	return t, err == nil
+}
+
+// overallScore returns the snapshot's score from either server format.
+func (s *HealthSnapshot) overallScore() float64 {
+	if s.Date != "" || s.OverallScore != 0 {
+		return s.OverallScore
+	}
+	return s.Score
+}
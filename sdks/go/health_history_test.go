@@ -0,0 +1,40 @@
+package agentlens
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestHealthHistoryAggregate(t *testing.T) {
+	var snaps []HealthSnapshot
+	json.Unmarshal([]byte(`[
+		{"agentId":"a1","timestamp":"2026-01-01T00:10:00Z","score":80},
+		{"agentId":"a1","timestamp":"2026-01-01T05:59:59Z","score":60},
+		{"agentId":"a1","timestamp":"2026-01-01T01:30:00Z","score":70},
+		{"agentId":"a1","timestamp":"2026-01-01T19:00:00Z","score":90},
+		{"agentId":"a1","timestamp":"not a time","score":10},
+		{"agentId":"a1","date":"2026-01-02","overallScore":50}]`), &snaps)
+
+	got := HealthHistory(snaps).Aggregate(6 * time.Hour)
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	want := []HealthBucket{
+		{Start: day, End: day.Add(6 * time.Hour), Count: 3, Avg: 70, Min: 60, Max: 80},
+		// Nothing between 06:00 and 18:00: no buckets are made up for it.
+		{Start: day.Add(18 * time.Hour), End: day.Add(24 * time.Hour), Count: 1, Avg: 90, Min: 90, Max: 90},
+		{Start: day.Add(24 * time.Hour), End: day.Add(30 * time.Hour), Count: 1, Avg: 50, Min: 50, Max: 50},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d buckets, got %+v", len(want), got)
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || !got[i].End.Equal(want[i].End) ||
+			got[i].Count != want[i].Count || got[i].Avg != want[i].Avg || got[i].Min != want[i].Min || got[i].Max != want[i].Max {
+			t.Errorf("bucket %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if HealthHistory(snaps).Aggregate(0) != nil {
+		t.Error("expected nil for a non-positive bucket")
+	}
+}
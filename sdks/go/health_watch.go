@@ -0,0 +1,78 @@
+package agentlens
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// defaultHealthWatchInterval is the WatchHealth poll interval when none is given.
+const defaultHealthWatchInterval = 30 * time.Second
+
+// maxHealthWatchBackoff caps the error backoff as a multiple (a power of
+// two) of the poll interval.
+const maxHealthWatchBackoff = 5 // 2^5 = 32 intervals
+
+// WatchHealth polls Health every interval (default 30s) and sends the first
+// reading, then each reading whose Status or Version differs from the last
+// one sent. Failed polls are sent on the error channel and slow polling
+// down, doubling the wait after each consecutive failure up to 32 intervals
+// (see RetryConfig.BackoffResetAfter for when the backoff resets); an error
+// is dropped if the previous one has not been received yet.
+// Errors are reported even in fail-open mode.
+//
+// Both channels are closed when ctx ends or the client is closed. Receive
+// from the results channel promptly: polling waits while a result is
+// pending.
+func (c *Client) WatchHealth(ctx context.Context, interval time.Duration) (<-chan HealthResult, <-chan error) {
+	if interval <= 0 {
+		interval = defaultHealthWatchInterval
+	}
+	results := make(chan HealthResult)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(results)
+		defer close(errs)
+		var last *HealthResult
+		backoff := loopBackoff{resetAfter: c.cfg.retry.BackoffResetAfter, clock: c.cfg.clock}
+		for {
+			var h HealthResult
+			err := c.do(ctx, http.MethodGet, "/api/health", nil, &h, true)
+			wait := interval
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err != nil:
+				select {
+				case errs <- err:
+				default:
+				}
+				wait = interval << min(backoff.failed(), maxHealthWatchBackoff)
+			default:
+				backoff.succeeded()
+				if last == nil || h.Status != last.Status || h.Version != last.Version {
+					select {
+					case results <- h:
+						last = &h
+					case <-ctx.Done():
+						return
+					case <-c.closing:
+						return
+					}
+				}
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-c.closing:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return results, errs
+}
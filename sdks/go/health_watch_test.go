@@ -0,0 +1,64 @@
+package agentlens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchHealth(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n := calls.Add(1); {
+		case n <= 2:
+			w.Write([]byte(`{"status":"ok","version":"1"}`))
+		case n == 3:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`{"status":"ok","version":"2"}`))
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key", WithRetry(RetryConfig{MaxRetries: 0}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, errs := c.WatchHealth(ctx, 5*time.Millisecond)
+
+	var got []HealthResult
+	var errCount int
+	timeout := time.After(2 * time.Second)
+	for len(got) < 2 {
+		select {
+		case h := <-results:
+			got = append(got, h)
+		case <-errs:
+			errCount++
+		case <-timeout:
+			t.Fatalf("timed out; got %+v", got)
+		}
+	}
+	if got[0].Version != "1" || got[1].Version != "2" {
+		t.Errorf("expected versions 1 then 2, got %+v", got)
+	}
+	if calls.Load() < 4 {
+		t.Errorf("expected the unchanged reading to be skipped, got %d polls", calls.Load())
+	}
+	select {
+	case <-errs:
+		errCount++
+	default:
+	}
+	if errCount != 1 {
+		t.Errorf("expected 1 error, got %d", errCount)
+	}
+
+	cancel()
+	for range results {
+	}
+	if _, ok := <-errs; ok {
+		t.Error("expected the error channel to be closed")
+	}
+}
@@ -0,0 +1,84 @@
+package agentlens
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type hedgingConfig struct {
+	after time.Duration
+	max   int
+}
+
+// roundTrip sends req with hc, hedging it if WithHedging is set and req is
+// a GET. release must be called once the response body has been read; it
+// cancels the winning request's context.
+func (c *Client) roundTrip(hc *http.Client, req *http.Request) (resp *http.Response, release func(), err error) {
+	h := c.cfg.hedging
+	if h == nil || h.max < 2 || req.Method != http.MethodGet {
+		resp, err := hc.Do(req)
+		return resp, func() {}, err
+	}
+
+	type result struct {
+		resp *http.Response
+		err  error
+		i    int // index into cancels
+	}
+	results := make(chan result, h.max)
+	var cancels []context.CancelFunc
+	launch := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		cancels = append(cancels, cancel)
+		r, i := req.Clone(ctx), len(cancels)-1
+		go func() {
+			resp, err := hc.Do(r)
+			results <- result{resp, err, i}
+		}()
+	}
+	cancelAll := func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+
+	launch()
+	inflight := 1
+	timer := time.NewTimer(h.after)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			// Hedges are retries as far as the retry budget is concerned.
+			if len(cancels) < h.max && (c.retryBudget == nil || c.retryBudget.withdraw()) {
+				launch()
+				inflight++
+				c.hedged.Add(1)
+				timer.Reset(h.after)
+			}
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				// Cancel the losers and discard any response they still produce.
+				for i, cancel := range cancels {
+					if i != r.i {
+						cancel()
+					}
+				}
+				go func(n int) {
+					for ; n > 0; n-- {
+						if late := <-results; late.resp != nil {
+							late.resp.Body.Close()
+						}
+					}
+				}(inflight)
+				return r.resp, cancels[r.i], nil
+			}
+			if inflight == 0 {
+				cancelAll()
+				return nil, func() {}, r.err
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package agentlens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedgingFasterReplicaWins(t *testing.T) {
+	var calls atomic.Int32
+	slowCancelled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+				close(slowCancelled)
+			case <-time.After(2 * time.Second):
+				w.Write([]byte(`{"id":"slow"}`))
+			}
+			return
+		}
+		w.Write([]byte(`{"id":"fast"}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key", WithHedging(20*time.Millisecond, 2))
+
+	start := time.Now()
+	agent, err := c.GetAgent(context.Background(), "a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agent.ID != "fast" {
+		t.Errorf("expected the hedged request's response, got %q", agent.ID)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("hedged GET took %v", elapsed)
+	}
+	select {
+	case <-slowCancelled:
+	case <-time.After(time.Second):
+		t.Error("expected the slow request to be cancelled")
+	}
+	if st := c.Stats(); st.HedgedRequests != 1 {
+		t.Errorf("expected 1 hedged request, got %d", st.HedgedRequests)
+	}
+
+	// Writes are never hedged.
+	calls.Store(1)
+	c.SendEvents(context.Background(), []Event{{SessionID: "s1", AgentID: "a1", EventType: "custom"}})
+	if calls.Load() != 2 || c.Stats().HedgedRequests != 1 {
+		t.Errorf("expected a single unhedged POST, got %d calls", calls.Load()-1)
+	}
+}
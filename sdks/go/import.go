@@ -0,0 +1,127 @@
+package agentlens
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxIngestBatch is the most events the server accepts per POST /api/events.
+const maxIngestBatch = 1000
+
+// ImportOpts configures ImportEvents.
+type ImportOpts struct {
+	// SessionID and AgentID, if set, replace the IDs of every imported event.
+	SessionID string
+	AgentID   string
+	// BatchSize is the number of events sent per request (default 100,
+	// at most 1000).
+	BatchSize int
+	// DryRun parses and validates the input without sending anything.
+	DryRun bool
+}
+
+// ImportLineError reports an NDJSON line that could not be imported.
+type ImportLineError struct {
+	// Line is the 1-based line number in the input.
+	Line int
+	Err  error
+}
+
+func (e *ImportLineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ImportLineError) Unwrap() error { return e.Err }
+
+// ImportResult summarizes an ImportEvents run.
+type ImportResult struct {
+	// Read is the number of non-blank lines read.
+	Read int
+	// Imported is the number of events sent, or that would have been sent
+	// in a dry run.
+	Imported int
+	// Errors lists the lines that were skipped because they were not valid
+	// JSON, failed Event.Validate, or were rejected by the server.
+	Errors []ImportLineError
+}
+
+// ImportEvents backfills events from r, which holds one JSON-encoded Event
+// per line (NDJSON). Lines that fail to parse or validate are recorded in
+// the result's Errors and skipped, as are events the server rejects (see
+// SendEventsWithResult); the rest are sent in batches of opts.BatchSize.
+// Sampling does not apply to imported events.
+//
+// A failed send or read stops the import: the error is returned together
+// with the result so far, whose Imported count covers only the batches the
+// server accepted. opts may be nil.
+func (c *Client) ImportEvents(ctx context.Context, r io.Reader, opts *ImportOpts) (*ImportResult, error) {
+	if opts == nil {
+		opts = &ImportOpts{}
+	}
+	size := opts.BatchSize
+	if size <= 0 {
+		size = 100
+	}
+	size = min(size, maxIngestBatch)
+
+	result := &ImportResult{}
+	batch := make([]Event, 0, size)
+	lines := make([]int, 0, size) // input line of each batch event
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		defer func() { batch, lines = batch[:0], lines[:0] }()
+		if opts.DryRun {
+			result.Imported += len(batch)
+			return nil
+		}
+		res, err := c.sendEventsPartial(ctx, batch)
+		for _, r := range res.Rejected {
+			result.Errors = append(result.Errors, ImportLineError{Line: lines[r.Index], Err: r.Err})
+		}
+		result.Imported += res.Accepted
+		return err
+	}
+
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, readErr := br.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return result, fmt.Errorf("agentlens: import: read line %d: %w", line, readErr)
+		}
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			result.Read++
+			var e Event
+			err := json.Unmarshal(data, &e)
+			if err == nil {
+				if opts.SessionID != "" {
+					e.SessionID = opts.SessionID
+				}
+				if opts.AgentID != "" {
+					e.AgentID = opts.AgentID
+				}
+				err = e.Validate()
+			}
+			if err != nil {
+				result.Errors = append(result.Errors, ImportLineError{Line: line, Err: err})
+			} else if batch, lines = append(batch, e), append(lines, line); len(batch) == size {
+				if err := flush(); err != nil {
+					return result, err
+				}
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
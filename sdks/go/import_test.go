@@ -0,0 +1,62 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestImportEvents(t *testing.T) {
+	var batches [][]Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		batches = append(batches, body.Events)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	input := strings.Join([]string{
+		`{"sessionId":"old","agentId":"a1","eventType":"custom","timestamp":"2024-01-01T00:00:00Z"}`,
+		`not json`,
+		``,
+		`{"sessionId":"old","eventType":"custom","severity":"loud"}`,
+		`{"sessionId":"old","agentId":"a1","eventType":"llm_call"}`,
+		`{"sessionId":"old","agentId":"a1","eventType":"llm_response"}`,
+	}, "\n")
+
+	c := NewClient(srv.URL, "key")
+	res, err := c.ImportEvents(context.Background(), strings.NewReader(input), &ImportOpts{SessionID: "s1", BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Read != 5 || res.Imported != 3 {
+		t.Errorf("Read=%d Imported=%d, want 5 and 3", res.Read, res.Imported)
+	}
+	if len(res.Errors) != 2 || res.Errors[0].Line != 2 || res.Errors[1].Line != 4 {
+		t.Fatalf("unexpected line errors: %+v", res.Errors)
+	}
+	if !strings.Contains(res.Errors[1].Error(), "severity") {
+		t.Errorf("expected a severity error, got %v", res.Errors[1].Error())
+	}
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+	if batches[0][0].SessionID != "s1" {
+		t.Errorf("SessionID override not applied: %q", batches[0][0].SessionID)
+	}
+
+	batches = nil
+	res, err = c.ImportEvents(context.Background(), strings.NewReader(input), &ImportOpts{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported != 3 || len(batches) != 0 {
+		t.Errorf("dry run: Imported=%d, %d requests sent", res.Imported, len(batches))
+	}
+}
@@ -0,0 +1,234 @@
+package agentlens
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// defaultAnalyticsWindow is the chunk size used when LlmAnalyticsParams.Window is zero.
+const defaultAnalyticsWindow = 7 * 24 * time.Hour
+
+// analyticsTimeFormat matches the millisecond ISO timestamps the server stores,
+// so adjacent windows can be made non-overlapping under its inclusive bounds.
+const analyticsTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// analyticsWindows splits params' [From, To] range into consecutive,
+// non-overlapping windows of params.Window. From and To default to the last
+// 24 hours, as on the server.
+func (c *Client) analyticsWindows(params *LlmAnalyticsParams) ([][2]string, error) {
+	to := c.cfg.clock.Now().UTC()
+	from := to.Add(-24 * time.Hour)
+	window := defaultAnalyticsWindow
+	if params != nil {
+		if params.To != nil {
+			t, err := time.Parse(time.RFC3339Nano, *params.To)
+			if err != nil {
+				return nil, fmt.Errorf("agentlens: invalid To: %w", err)
+			}
+			to = t.UTC()
+		}
+		if params.From != nil {
+			t, err := time.Parse(time.RFC3339Nano, *params.From)
+			if err != nil {
+				return nil, fmt.Errorf("agentlens: invalid From: %w", err)
+			}
+			from = t.UTC()
+		}
+		if params.Window > 0 {
+			window = params.Window
+		}
+	}
+	var windows [][2]string
+	for start := from; !start.After(to); start = start.Add(window) {
+		end := start.Add(window)
+		if end.After(to) {
+			windows = append(windows, [2]string{start.Format(analyticsTimeFormat), to.Format(analyticsTimeFormat)})
+			break
+		}
+		// Both server bounds are inclusive; stop 1ms short of the next window.
+		windows = append(windows, [2]string{start.Format(analyticsTimeFormat), end.Add(-time.Millisecond).Format(analyticsTimeFormat)})
+	}
+	return windows, nil
+}
+
+// getLlmAnalyticsWindow fetches analytics for a single window.
+func (c *Client) getLlmAnalyticsWindow(ctx context.Context, params *LlmAnalyticsParams, from, to string) (*LlmAnalyticsResult, error) {
+	p := url.Values{}
+	p.Set("from", from)
+	p.Set("to", to)
+	if params != nil {
+		addQueryParam(&p, "agentId", params.AgentID)
+		addQueryParam(&p, "model", params.Model)
+		addQueryParam(&p, "provider", params.Provider)
+		addQueryParam(&p, "granularity", params.Granularity)
+		addQueryParam(&p, "groupBy", params.GroupBy)
+	}
+	var result LlmAnalyticsResult
+	err := c.do(ctx, http.MethodGet, "/api/analytics/llm?"+p.Encode(), nil, &result, false)
+	return &result, err
+}
+
+// GetLlmAnalyticsChunked is like GetLlmAnalytics but fetches the range in
+// windows of params.Window (default 7 days) and merges them with
+// MergeLlmAnalytics, keeping each response small for long ranges.
+func (c *Client) GetLlmAnalyticsChunked(ctx context.Context, params *LlmAnalyticsParams) (*LlmAnalyticsResult, error) {
+	windows, err := c.analyticsWindows(params)
+	if err != nil {
+		return &LlmAnalyticsResult{}, err
+	}
+	parts := make([]*LlmAnalyticsResult, 0, len(windows))
+	for _, w := range windows {
+		part, err := c.getLlmAnalyticsWindow(ctx, params, w[0], w[1])
+		if err != nil {
+			var result LlmAnalyticsResult
+			return &result, c.failOpen(err, &result)
+		}
+		parts = append(parts, part)
+	}
+	return MergeLlmAnalytics(parts...), nil
+}
+
+// StreamLlmAnalytics pages through the ByTime buckets for params' range one
+// window (params.Window, default 7 days) at a time, so only one window is
+// held in memory. A bucket split across two windows is merged before it is
+// sent. Both channels are closed when the stream ends; the error channel
+// receives at most one error. Cancel ctx to stop early.
+func (c *Client) StreamLlmAnalytics(ctx context.Context, params *LlmAnalyticsParams) (<-chan LlmAnalyticsByTime, <-chan error) {
+	out := make(chan LlmAnalyticsByTime)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(out)
+		defer close(errc)
+		windows, err := c.analyticsWindows(params)
+		if err != nil {
+			errc <- err
+			return
+		}
+		send := func(b LlmAnalyticsByTime) bool {
+			select {
+			case out <- b:
+				return true
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return false
+			}
+		}
+		var pending *LlmAnalyticsByTime
+		for _, w := range windows {
+			part, err := c.getLlmAnalyticsWindow(ctx, params, w[0], w[1])
+			if err != nil {
+				if err = c.failOpen(err, nil); err != nil {
+					errc <- err
+				}
+				return
+			}
+			for _, b := range part.ByTime {
+				if pending != nil && pending.Bucket == b.Bucket {
+					merged := mergeByTime(*pending, b)
+					pending = &merged
+					continue
+				}
+				if pending != nil && !send(*pending) {
+					return
+				}
+				b := b
+				pending = &b
+			}
+		}
+		if pending != nil {
+			send(*pending)
+		}
+	}()
+	return out, errc
+}
+
+// MergeLlmAnalytics combines results for adjacent, non-overlapping time
+// ranges. Totals are summed, averages are re-weighted by call count, and
+// ByModel, ByTime and ByTag entries with the same key are combined.
+func MergeLlmAnalytics(results ...*LlmAnalyticsResult) *LlmAnalyticsResult {
+	merged := &LlmAnalyticsResult{}
+	s := &merged.Summary
+	var latencySum float64
+	modelIdx := map[string]int{}
+	timeIdx := map[string]int{}
+	tagIdx := map[string]int{}
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		s.TotalCalls += r.Summary.TotalCalls
+		s.TotalCostUsd += r.Summary.TotalCostUsd
+		s.TotalInputTokens += r.Summary.TotalInputTokens
+		s.TotalOutputTokens += r.Summary.TotalOutputTokens
+		latencySum += r.Summary.AvgLatencyMs * float64(r.Summary.TotalCalls)
+
+		for _, m := range r.ByModel {
+			key := m.Provider + "\x00" + m.Model
+			i, ok := modelIdx[key]
+			if !ok {
+				modelIdx[key] = len(merged.ByModel)
+				merged.ByModel = append(merged.ByModel, m)
+				continue
+			}
+			cur := &merged.ByModel[i]
+			cur.AvgLatencyMs = weightedAvg(cur.AvgLatencyMs, cur.Calls, m.AvgLatencyMs, m.Calls)
+			cur.Calls += m.Calls
+			cur.CostUsd += m.CostUsd
+			cur.InputTokens += m.InputTokens
+			cur.OutputTokens += m.OutputTokens
+		}
+		for _, b := range r.ByTime {
+			if i, ok := timeIdx[b.Bucket]; ok {
+				merged.ByTime[i] = mergeByTime(merged.ByTime[i], b)
+				continue
+			}
+			timeIdx[b.Bucket] = len(merged.ByTime)
+			merged.ByTime = append(merged.ByTime, b)
+		}
+		for _, t := range r.ByTag {
+			i, ok := tagIdx[t.Value]
+			if !ok {
+				tagIdx[t.Value] = len(merged.ByTag)
+				merged.ByTag = append(merged.ByTag, t)
+				continue
+			}
+			cur := &merged.ByTag[i]
+			cur.AvgLatencyMs = weightedAvg(cur.AvgLatencyMs, cur.Calls, t.AvgLatencyMs, t.Calls)
+			cur.Calls += t.Calls
+			cur.CostUsd += t.CostUsd
+			cur.InputTokens += t.InputTokens
+			cur.OutputTokens += t.OutputTokens
+		}
+	}
+	// Match the server's ordering of the model breakdown.
+	sort.SliceStable(merged.ByModel, func(i, j int) bool { return merged.ByModel[i].CostUsd > merged.ByModel[j].CostUsd })
+	sort.SliceStable(merged.ByTag, func(i, j int) bool { return merged.ByTag[i].CostUsd > merged.ByTag[j].CostUsd })
+	if s.TotalCalls > 0 {
+		s.AvgLatencyMs = latencySum / float64(s.TotalCalls)
+		s.AvgCostPerCall = s.TotalCostUsd / float64(s.TotalCalls)
+	}
+	return merged
+}
+
+// mergeByTime combines two partial aggregates for the same bucket.
+func mergeByTime(a, b LlmAnalyticsByTime) LlmAnalyticsByTime {
+	return LlmAnalyticsByTime{
+		Bucket:       a.Bucket,
+		Calls:        a.Calls + b.Calls,
+		CostUsd:      a.CostUsd + b.CostUsd,
+		InputTokens:  a.InputTokens + b.InputTokens,
+		OutputTokens: a.OutputTokens + b.OutputTokens,
+		AvgLatencyMs: weightedAvg(a.AvgLatencyMs, a.Calls, b.AvgLatencyMs, b.Calls),
+	}
+}
+
+func weightedAvg(a float64, na int, b float64, nb int) float64 {
+	if na+nb == 0 {
+		return 0
+	}
+	return (a*float64(na) + b*float64(nb)) / float64(na+nb)
+}
@@ -0,0 +1,130 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// twoWindowServer answers /api/analytics/llm with one result per day window.
+func twoWindowServer(t *testing.T, windows *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+		*windows = append(*windows, from+"/"+to)
+		var res LlmAnalyticsResult
+		if strings.HasPrefix(from, "2024-01-01") {
+			res = LlmAnalyticsResult{
+				Summary: LlmAnalyticsSummary{TotalCalls: 2, TotalCostUsd: 1, TotalInputTokens: 10, TotalOutputTokens: 20, AvgLatencyMs: 100},
+				ByModel: []LlmAnalyticsByModel{{Provider: "openai", Model: "gpt-4", Calls: 2, CostUsd: 1, AvgLatencyMs: 100}},
+				ByTime: []LlmAnalyticsByTime{
+					{Bucket: "a", Calls: 1, CostUsd: 0.5, AvgLatencyMs: 100},
+					{Bucket: "shared", Calls: 1, CostUsd: 0.5, AvgLatencyMs: 100},
+				},
+			}
+		} else {
+			res = LlmAnalyticsResult{
+				Summary: LlmAnalyticsSummary{TotalCalls: 6, TotalCostUsd: 3, TotalInputTokens: 30, TotalOutputTokens: 60, AvgLatencyMs: 200},
+				ByModel: []LlmAnalyticsByModel{{Provider: "openai", Model: "gpt-4", Calls: 6, CostUsd: 3, AvgLatencyMs: 200}},
+				ByTime: []LlmAnalyticsByTime{
+					{Bucket: "shared", Calls: 3, CostUsd: 1.5, AvgLatencyMs: 200},
+					{Bucket: "b", Calls: 3, CostUsd: 1.5, AvgLatencyMs: 200},
+				},
+			}
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+}
+
+func twoDayParams() *LlmAnalyticsParams {
+	from, to := "2024-01-01T00:00:00Z", "2024-01-02T23:59:59.999Z"
+	return &LlmAnalyticsParams{From: &from, To: &to, Window: 24 * time.Hour}
+}
+
+func TestGetLlmAnalyticsChunkedMergesWindows(t *testing.T) {
+	var windows []string
+	srv := twoWindowServer(t, &windows)
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	res, err := c.GetLlmAnalyticsChunked(context.Background(), twoDayParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"2024-01-01T00:00:00.000Z/2024-01-01T23:59:59.999Z",
+		"2024-01-02T00:00:00.000Z/2024-01-02T23:59:59.999Z",
+	}
+	if strings.Join(windows, " ") != strings.Join(want, " ") {
+		t.Errorf("unexpected windows: %v", windows)
+	}
+
+	s := res.Summary
+	if s.TotalCalls != 8 || s.TotalCostUsd != 4 || s.TotalInputTokens != 40 || s.TotalOutputTokens != 80 {
+		t.Errorf("unexpected totals: %+v", s)
+	}
+	// (2*100 + 6*200) / 8 = 175
+	if math.Abs(s.AvgLatencyMs-175) > 1e-9 || s.AvgCostPerCall != 0.5 {
+		t.Errorf("unexpected averages: %+v", s)
+	}
+	if len(res.ByModel) != 1 || res.ByModel[0].Calls != 8 || math.Abs(res.ByModel[0].AvgLatencyMs-175) > 1e-9 {
+		t.Errorf("unexpected byModel: %+v", res.ByModel)
+	}
+	if len(res.ByTime) != 3 || res.ByTime[1].Bucket != "shared" || res.ByTime[1].Calls != 4 || res.ByTime[1].AvgLatencyMs != 175 {
+		t.Errorf("unexpected byTime: %+v", res.ByTime)
+	}
+}
+
+func TestStreamLlmAnalytics(t *testing.T) {
+	var windows []string
+	srv := twoWindowServer(t, &windows)
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	buckets, errc := c.StreamLlmAnalytics(context.Background(), twoDayParams())
+	var got []LlmAnalyticsByTime
+	for b := range buckets {
+		got = append(got, b)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].Bucket != "a" || got[1].Bucket != "shared" || got[1].Calls != 4 || got[2].Bucket != "b" {
+		t.Errorf("unexpected buckets: %+v", got)
+	}
+}
+
+func TestGetLlmAnalyticsGroupBy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("groupBy"); got != "team" {
+			t.Errorf("groupBy = %q", got)
+		}
+		w.Write([]byte(`{"summary":{"totalCalls":3},"byModel":[],"byTime":[],"byTag":[
+			{"value":"search","calls":2,"costUsd":0.75,"inputTokens":100,"outputTokens":40,"avgLatencyMs":120},
+			{"value":"","calls":1,"costUsd":0.25,"inputTokens":10,"outputTokens":5,"avgLatencyMs":80}]}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	team := "team"
+	res, err := c.GetLlmAnalytics(context.Background(), &LlmAnalyticsParams{GroupBy: &team})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LlmAnalyticsByTag{
+		{Value: "search", Calls: 2, CostUsd: 0.75, InputTokens: 100, OutputTokens: 40, AvgLatencyMs: 120},
+		{Value: "", Calls: 1, CostUsd: 0.25, InputTokens: 10, OutputTokens: 5, AvgLatencyMs: 80},
+	}
+	if len(res.ByTag) != 2 || res.ByTag[0] != want[0] || res.ByTag[1] != want[1] {
+		t.Errorf("ByTag = %+v", res.ByTag)
+	}
+
+	merged := MergeLlmAnalytics(res, res)
+	if len(merged.ByTag) != 2 || merged.ByTag[0].Calls != 4 || merged.ByTag[0].AvgLatencyMs != 120 {
+		t.Errorf("merged ByTag = %+v", merged.ByTag)
+	}
+}
@@ -0,0 +1,138 @@
+package agentlens
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LlmCallHandle tracks an in-flight streaming LLM call started with StartLlmCall.
+type LlmCallHandle struct {
+	c         *Client
+	sessionID string
+	agentID   string
+	callID    string
+	params    LogLlmCallParams
+	startedAt time.Time
+
+	sampledOut bool // dropped by sampling; nothing is sent
+
+	mu           sync.Mutex
+	completion   strings.Builder
+	firstTokenAt time.Time
+	finished     bool
+}
+
+// StartLlmCall emits the llm_call event immediately and returns a handle that
+// accumulates streamed deltas. Call Finish on the handle to emit the paired
+// llm_response event. Completion, Usage, CostUsd, LatencyMs and FinishReason
+// on params are ignored; they are supplied through the handle instead.
+func (c *Client) StartLlmCall(ctx context.Context, sessionID, agentID string, params *LogLlmCallParams) (*LlmCallHandle, error) {
+	h := &LlmCallHandle{
+		c:         c,
+		sessionID: sessionID,
+		agentID:   agentID,
+		callID:    generateID(),
+		params:    *params,
+		startedAt: c.cfg.clock.Now(),
+	}
+	if !c.keepEvent(sessionID, agentID, SeverityInfo) {
+		h.sampledOut = true
+		return h, nil
+	}
+	timestamp := h.startedAt.UTC().Format(time.RFC3339Nano)
+	e := sdkEvent(sessionID, agentID, EventTypeLlmCall, SeverityInfo, llmCallPayload(h.callID, params), timestamp)
+	if err := c.postEvents(ctx, sessionID, agentID, []Event{e}, nil); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// CallID returns the generated call ID shared by the llm_call and llm_response events.
+func (h *LlmCallHandle) CallID() string { return h.callID }
+
+// AppendDelta appends a streamed chunk of completion text. The first call
+// records the time-to-first-token latency. Thread-safe.
+func (h *LlmCallHandle) AppendDelta(text string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.firstTokenAt.IsZero() {
+		h.firstTokenAt = h.c.cfg.clock.Now()
+	}
+	h.completion.WriteString(text)
+}
+
+// FirstTokenLatency returns the time between StartLlmCall and the first
+// AppendDelta, or zero if no delta has been appended yet.
+func (h *LlmCallHandle) FirstTokenLatency() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.firstTokenAt.IsZero() {
+		return 0
+	}
+	return h.firstTokenAt.Sub(h.startedAt)
+}
+
+// Finish emits the llm_response event with the accumulated completion.
+// If latencyMs is zero, the elapsed time since StartLlmCall is used; if
+// costUsd is zero, it is estimated from the client's pricing table, if any.
+// Finish may only be called once.
+func (h *LlmCallHandle) Finish(ctx context.Context, usage LlmUsage, finishReason string, costUsd, latencyMs float64) error {
+	h.mu.Lock()
+	if h.finished {
+		h.mu.Unlock()
+		return errors.New("agentlens: llm call already finished")
+	}
+	h.finished = true
+	if h.sampledOut {
+		h.mu.Unlock()
+		return nil
+	}
+	now := h.c.cfg.clock.Now()
+	completion := h.completion.String()
+	var firstTokenMs *float64
+	if !h.firstTokenAt.IsZero() {
+		v := float64(h.firstTokenAt.Sub(h.startedAt)) / float64(time.Millisecond)
+		firstTokenMs = &v
+	}
+	h.mu.Unlock()
+
+	if costUsd == 0 {
+		p := h.params
+		p.Usage, p.CostUsd = usage, 0
+		costUsd = h.c.fillCost(&p)
+	}
+	if latencyMs == 0 {
+		latencyMs = float64(now.Sub(h.startedAt)) / float64(time.Millisecond)
+	}
+	if h.params.Redact {
+		completion = redactedPlaceholder
+	}
+
+	payload := map[string]any{
+		"callId":       h.callID,
+		"provider":     h.params.Provider,
+		"model":        h.params.Model,
+		"completion":   completion,
+		"finishReason": finishReason,
+		"usage":        usage,
+		"costUsd":      costUsd,
+		"latencyMs":    latencyMs,
+		"streamed":     true,
+	}
+	if firstTokenMs != nil {
+		payload["firstTokenLatencyMs"] = *firstTokenMs
+	}
+	if h.params.ToolCalls != nil {
+		payload["toolCalls"] = h.params.ToolCalls
+	}
+	if h.params.Redact {
+		payload["redacted"] = true
+	}
+
+	e := sdkEvent(h.sessionID, h.agentID, EventTypeLlmResponse, SeverityInfo, payload, now.UTC().Format(time.RFC3339Nano))
+	e.Metadata = providerMetadata(&h.params)
+	return h.c.postEvents(ctx, h.sessionID, h.agentID, []Event{e}, nil)
+}
@@ -0,0 +1,76 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartLlmCallStreaming(t *testing.T) {
+	var mu sync.Mutex
+	var received []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []map[string]any `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		received = append(received, body.Events...)
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key")
+	h, err := c.StartLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{
+		Provider: "openai",
+		Model:    "gpt-4",
+		Messages: []LlmMessage{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	if len(received) != 1 || received[0]["eventType"] != "llm_call" {
+		t.Fatalf("expected llm_call to be sent immediately, got %+v", received)
+	}
+	mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+	h.AppendDelta("Hel")
+	h.AppendDelta("lo!")
+	if h.FirstTokenLatency() <= 0 {
+		t.Error("expected first-token latency to be recorded")
+	}
+
+	if err := h.Finish(context.Background(), LlmUsage{TotalTokens: 3}, "stop", 0.001, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Finish(context.Background(), LlmUsage{}, "stop", 0, 0); err == nil {
+		t.Error("expected error on second Finish")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(received))
+	}
+	resp := received[1]["payload"].(map[string]any)
+	if resp["completion"] != "Hello!" {
+		t.Errorf("unexpected completion: %v", resp["completion"])
+	}
+	if resp["callId"] != h.CallID() {
+		t.Errorf("callId mismatch: %v != %s", resp["callId"], h.CallID())
+	}
+	if _, ok := resp["firstTokenLatencyMs"]; !ok {
+		t.Error("expected firstTokenLatencyMs in payload")
+	}
+	if resp["latencyMs"].(float64) <= 0 {
+		t.Error("expected latencyMs to be filled from elapsed time")
+	}
+}
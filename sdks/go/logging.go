@@ -0,0 +1,91 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Redactor scrubs sensitive data from a JSON body before it is logged.
+type Redactor func(body []byte) []byte
+
+// sensitiveKeys are JSON object keys whose values DefaultRedactor masks.
+// Matching is case-insensitive.
+var sensitiveKeys = map[string]bool{
+	"apikey":        true,
+	"api_key":       true,
+	"authorization": true,
+	"token":         true,
+	"secret":        true,
+	"password":      true,
+	"messages":      true,
+	"systemprompt":  true,
+	"completion":    true,
+	"prompt":        true,
+}
+
+// DefaultRedactor masks credentials and prompt/completion content in JSON
+// bodies. Bodies that are not valid JSON are replaced entirely.
+func DefaultRedactor(body []byte) []byte {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []byte(redactedPlaceholder)
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return []byte(redactedPlaceholder)
+	}
+	return out
+}
+
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if sensitiveKeys[strings.ToLower(k)] {
+				t[k] = redactedPlaceholder
+			} else {
+				t[k] = redactValue(val)
+			}
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = redactValue(val)
+		}
+	}
+	return v
+}
+
+// logAttempt logs a single request attempt at debug level. Headers are never
+// logged, so the Authorization header cannot leak.
+func (c *Client) logAttempt(ctx context.Context, method, path string, attempt, status int, dur time.Duration, reqBody, respBody []byte, err error) {
+	l := c.cfg.logger
+	if l == nil || !l.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("path", path),
+		slog.Int("attempt", attempt),
+		slog.Int("status", status),
+		slog.Duration("duration", dur),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if c.cfg.logBodies {
+		redact := c.cfg.redactor
+		if redact == nil {
+			redact = DefaultRedactor
+		}
+		if len(reqBody) > 0 {
+			attrs = append(attrs, slog.String("requestBody", string(redact(reqBody))))
+		}
+		if len(respBody) > 0 {
+			attrs = append(attrs, slog.String("responseBody", string(redact(respBody))))
+		}
+	}
+	l.LogAttrs(ctx, slog.LevelDebug, "agentlens request", attrs...)
+}
@@ -0,0 +1,62 @@
+package agentlens
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestLoggingRedacts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := NewClient(srv.URL, "super-secret-key", WithLogger(logger), WithLogBodies(true))
+
+	_, err := c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{
+		Provider: "openai",
+		Model:    "gpt-4",
+		Messages: []LlmMessage{{Role: "user", Content: "my private prompt"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"method":"POST"`) || !strings.Contains(out, `"path":"/api/events"`) || !strings.Contains(out, `"status":200`) {
+		t.Errorf("expected method/path/status in log, got: %s", out)
+	}
+	if strings.Contains(out, "super-secret-key") || strings.Contains(out, "Bearer") {
+		t.Errorf("bearer token leaked into log: %s", out)
+	}
+	if strings.Contains(out, "my private prompt") {
+		t.Errorf("prompt leaked into log: %s", out)
+	}
+	if !strings.Contains(out, "requestBody") {
+		t.Errorf("expected redacted request body in log, got: %s", out)
+	}
+}
+
+func TestRequestLoggingSkipsBodiesByDefault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := NewClient(srv.URL, "key", WithLogger(logger))
+	if _, err := c.Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "responseBody") {
+		t.Errorf("bodies should not be logged without WithLogBodies: %s", buf.String())
+	}
+}
@@ -0,0 +1,112 @@
+package agentlens
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Recommendation types, as reported in Recommendation.Type.
+const (
+	RecommendationModelDowngrade     = "model_downgrade"
+	RecommendationPromptOptimization = "prompt_optimization"
+	RecommendationCaching            = "caching"
+	RecommendationToolReduction      = "tool_reduction"
+)
+
+// Recommendation is a single cost optimization recommendation.
+type Recommendation struct {
+	// Type is one of the Recommendation* constants, or a type added by a
+	// newer server.
+	Type string `json:"type"`
+	// Title is a one-line summary. For model downgrades the server sends
+	// none, so it is derived from the models, e.g. "Switch from gpt-4o to
+	// gpt-4o-mini".
+	Title string `json:"title"`
+	// EstimatedSavingsUsd is the estimated monthly saving in USD.
+	EstimatedSavingsUsd float64 `json:"estimatedSavingsUsd"`
+	AgentID             string  `json:"agentId"`
+	// Details holds the remaining, type-specific fields; see ModelDowngrade.
+	Details map[string]any `json:"details,omitempty"`
+	// Raw is the recommendation as returned by the server.
+	Raw json.RawMessage `json:"-"`
+}
+
+// ModelDowngradeDetail describes a recommended switch to a cheaper model.
+type ModelDowngradeDetail struct {
+	CurrentModel     string `json:"currentModel"`
+	RecommendedModel string `json:"recommendedModel"`
+	// ComplexityTier is the tier of calls the switch applies to: "simple",
+	// "moderate", "complex" or "expert".
+	ComplexityTier         string  `json:"complexityTier"`
+	CurrentCostPerCall     float64 `json:"currentCostPerCall"`
+	RecommendedCostPerCall float64 `json:"recommendedCostPerCall"`
+	CallVolume             int     `json:"callVolume"`
+	CurrentSuccessRate     float64 `json:"currentSuccessRate"`
+	RecommendedSuccessRate float64 `json:"recommendedSuccessRate"`
+}
+
+// UnmarshalJSON accepts both the server's basic recommendations, which are
+// all model downgrades, and its categorized ones.
+func (r *Recommendation) UnmarshalJSON(data []byte) error {
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	take := func(keys ...string) any {
+		for _, k := range keys {
+			if v, ok := fields[k]; ok {
+				delete(fields, k)
+				return v
+			}
+		}
+		return nil
+	}
+	*r = Recommendation{Raw: append(json.RawMessage(nil), data...)}
+	r.Type, _ = take("category", "type").(string)
+	r.Title, _ = take("title").(string)
+	r.EstimatedSavingsUsd, _ = take("estimatedMonthlySavings", "monthlySavings", "estimatedSavingsUsd").(float64)
+	r.AgentID, _ = take("agentId").(string)
+	r.Details = fields
+	if r.Type == "" && fields["recommendedModel"] != nil {
+		r.Type = RecommendationModelDowngrade
+	}
+	if r.Title == "" {
+		if d, ok := r.ModelDowngrade(); ok {
+			r.Title = fmt.Sprintf("Switch from %s to %s", d.CurrentModel, d.RecommendedModel)
+		}
+	}
+	return nil
+}
+
+// ModelDowngrade returns the details of a model downgrade recommendation.
+// ok is false for other types.
+func (r Recommendation) ModelDowngrade() (detail *ModelDowngradeDetail, ok bool) {
+	if r.Type != RecommendationModelDowngrade {
+		return nil, false
+	}
+	var src any = r.Details
+	if nested, ok := r.Details["modelDowngrade"]; ok {
+		src = nested
+	}
+	detail = &ModelDowngradeDetail{}
+	if remarshal(src, detail) != nil {
+		return nil, false
+	}
+	return detail, true
+}
+
+// ByType returns the recommendations of the given type.
+func (r *OptimizationResult) ByType(recType string) []Recommendation {
+	var out []Recommendation
+	for _, rec := range r.Recommendations {
+		if rec.Type == recType {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// ModelDowngradeRecommendations returns the model downgrade recommendations.
+func (r *OptimizationResult) ModelDowngradeRecommendations() []Recommendation {
+	return r.ByType(RecommendationModelDowngrade)
+}
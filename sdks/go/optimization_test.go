@@ -0,0 +1,57 @@
+package agentlens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOptimizationRecommendations(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"recommendations": [
+				{"currentModel":"gpt-4o","recommendedModel":"gpt-4o-mini","complexityTier":"simple",
+				 "currentCostPerCall":0.01,"recommendedCostPerCall":0.001,"monthlySavings":42.5,
+				 "callVolume":1200,"currentSuccessRate":0.98,"recommendedSuccessRate":0.97,
+				 "confidence":"high","agentId":"a1"},
+				{"id":"r2","category":"caching","estimatedMonthlySavings":10,"agentId":"a2",
+				 "caching":{"hitRate":0.4}}
+			],
+			"totalPotentialSavings": 52.5, "period": 7, "analyzedCalls": 5000
+		}`))
+	}))
+	defer srv.Close()
+
+	res, err := NewClient(srv.URL, "key").GetOptimizationRecommendations(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalPotentialSavings != 52.5 || res.AnalyzedCalls != 5000 || len(res.Recommendations) != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	downgrades := res.ModelDowngradeRecommendations()
+	if len(downgrades) != 1 {
+		t.Fatalf("expected 1 model downgrade, got %d", len(downgrades))
+	}
+	rec := downgrades[0]
+	if rec.AgentID != "a1" || rec.EstimatedSavingsUsd != 42.5 || rec.Title != "Switch from gpt-4o to gpt-4o-mini" {
+		t.Errorf("unexpected recommendation: %+v", rec)
+	}
+	d, ok := rec.ModelDowngrade()
+	if !ok || d.RecommendedModel != "gpt-4o-mini" || d.CallVolume != 1200 {
+		t.Errorf("unexpected downgrade detail: %+v", d)
+	}
+
+	caching := res.ByType(RecommendationCaching)
+	if len(caching) != 1 || caching[0].EstimatedSavingsUsd != 10 || caching[0].Details["caching"] == nil {
+		t.Errorf("unexpected caching recommendation: %+v", caching)
+	}
+	if _, ok := caching[0].ModelDowngrade(); ok {
+		t.Error("ModelDowngrade should fail for a caching recommendation")
+	}
+	if len(caching[0].Raw) == 0 {
+		t.Error("Raw should hold the server's JSON")
+	}
+}
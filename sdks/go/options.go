@@ -1,8 +1,13 @@
 package agentlens
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -18,13 +23,132 @@ type clientConfig struct {
 	failOpen   bool
 	onError    func(error)
 	logger     *slog.Logger
+	pricing    *PricingTable
+
+	failOpenSentinel bool
+	logBodies        bool
+	redactor         Redactor
+	middleware       []func(http.RoundTripper) http.RoundTripper
+	requestModifiers []func(*http.Request) error
+	apiKeyProvider   func(context.Context) (string, error)
+	apiKeyTTL        time.Duration
+	authHeader       string
+	authPrefix       string
+	dryRun           func(method, path string, body any)
+	validate         bool
+	auditSigningKey  []byte
+	userAgent        string
+	clock            clock
+	cacheEntries     int
+	defaultMetadata  map[string]any
+	metadataInjector func(*Event)
+	interceptor      func(*Event) bool
+	batching         bool
+	batchOpts        []BatchOption
+	replayOnStart    bool
+	maxResponseBytes int64
+	retryBudget      *retryBudgetConfig
+	proxyURL         string
+	tlsConfig        *tls.Config
+	connPool         *connPoolConfig
+	payloadLimit     *payloadLimit
+	environment      string
+	tenantID         string
+	appName          string
+	sampler          func(agentID string) float64
+	healthSampling   *healthSamplingConfig
+	sessionSampling  bool
+	sessionRate      float64
+	rateLimit        *rateLimitConfig
+	retryPredicate   func(err error, attempt int) bool
+	hedging          *hedgingConfig
+	maxInFlight      int
+	strictDecoding   bool
+}
+
+type rateLimitConfig struct {
+	rps        float64
+	burst      int
+	skipHealth bool
+}
+
+type connPoolConfig struct {
+	maxIdle         int
+	maxIdlePerHost  int
+	maxConnsPerHost int
+	idleTimeout     time.Duration
+}
+
+type retryBudgetConfig struct {
+	ratio     float64
+	minPerSec int
 }
 
 func defaultConfig() clientConfig {
 	return clientConfig{
-		timeout: 30 * time.Second,
-		retry:   defaultRetryConfig(),
+		timeout:    30 * time.Second,
+		retry:      defaultRetryConfig(),
+		apiKeyTTL:  5 * time.Minute,
+		authHeader: "Authorization",
+		authPrefix: "Bearer ",
+		clock:      realClock{},
+
+		maxResponseBytes: defaultMaxResponseBytes,
+	}
+}
+
+// Request headers set by WithEnvironment, WithTenant and WithClientName.
+const (
+	environmentHeader = "X-AgentLens-Env"
+	tenantHeader      = "X-Tenant-ID"
+	appNameHeader     = "X-App-Name"
+)
+
+// applyTags adds the WithEnvironment, WithTenant and WithClientName values
+// to the default event metadata. The caller's map is copied, not modified.
+func (c *clientConfig) applyTags() {
+	if c.environment == "" && c.tenantID == "" && c.appName == "" {
+		return
 	}
+	md := make(map[string]any, len(c.defaultMetadata)+3)
+	if c.environment != "" {
+		md["environment"] = c.environment
+	}
+	if c.tenantID != "" {
+		md["tenantId"] = c.tenantID
+	}
+	if c.appName != "" {
+		md["appName"] = c.appName
+	}
+	for k, v := range c.defaultMetadata {
+		md[k] = v
+	}
+	c.defaultMetadata = md
+}
+
+// Validate checks the configuration for mistakes that would otherwise
+// surface later as cryptic connection errors: an empty server URL, or one
+// that is unparseable, lacks an http/https scheme, or has no host.
+func (c *clientConfig) Validate() error {
+	if c.url == "" {
+		return errors.New("agentlens: invalid configuration: server URL is empty")
+	}
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return fmt.Errorf("agentlens: invalid configuration: server URL %q: %w", c.url, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("agentlens: invalid configuration: server URL %q must start with http:// or https://", c.url)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("agentlens: invalid configuration: server URL %q has no host", c.url)
+	}
+	if c.proxyURL != "" {
+		if p, err := url.Parse(c.proxyURL); err != nil || p.Host == "" {
+			return fmt.Errorf("agentlens: invalid configuration: proxy URL %q", c.proxyURL)
+		}
+	}
+	return nil
 }
 
 // WithTimeout sets the HTTP request timeout (default 30s).
@@ -37,7 +161,8 @@ func WithRetry(cfg RetryConfig) ClientOption {
 	return func(c *clientConfig) { c.retry = cfg }
 }
 
-// WithHTTPClient provides a custom *http.Client.
+// WithHTTPClient provides a custom *http.Client. Middleware registered with
+// WithMiddleware wraps this client's transport.
 func WithHTTPClient(hc *http.Client) ClientOption {
 	return func(c *clientConfig) { c.httpClient = hc }
 }
@@ -50,7 +175,307 @@ func WithFailOpen(onErr func(error)) ClientOption {
 	}
 }
 
-// WithLogger sets the logger for internal warnings.
+// WithFailOpenSentinel enables fail-open mode and records each suppressed error
+// so it can be retrieved with Client.LastError. Result types that embed
+// FailOpenStatus have Suppressed set when their call failed. Combine with
+// WithFailOpen to also receive errors via callback.
+func WithFailOpenSentinel() ClientOption {
+	return func(c *clientConfig) {
+		c.failOpen = true
+		c.failOpenSentinel = true
+	}
+}
+
+// WithLogger sets the logger for internal warnings. At debug level, each
+// request attempt is logged with its method, path, status, and duration.
 func WithLogger(l *slog.Logger) ClientOption {
 	return func(c *clientConfig) { c.logger = l }
 }
+
+// WithPricingTable enables automatic cost estimation. LogLlmCall fills CostUsd
+// from pt when the caller leaves it zero. See DefaultPricingTable.
+func WithPricingTable(pt *PricingTable) ClientOption {
+	return func(c *clientConfig) { c.pricing = pt }
+}
+
+// WithLogBodies includes request and response bodies in debug request logs.
+// Bodies are passed through the configured Redactor (DefaultRedactor unless
+// overridden with WithRedactor) before logging. Has no effect without WithLogger.
+func WithLogBodies(enabled bool) ClientOption {
+	return func(c *clientConfig) { c.logBodies = enabled }
+}
+
+// WithRedactor sets the function used to scrub bodies before they are logged.
+func WithRedactor(r Redactor) ClientOption {
+	return func(c *clientConfig) { c.redactor = r }
+}
+
+// WithMiddleware wraps the client's transport, e.g. to inject headers or record
+// metrics. Multiple middlewares chain in registration order: the first one
+// registered sees each request first.
+func WithMiddleware(fn func(http.RoundTripper) http.RoundTripper) ClientOption {
+	return func(c *clientConfig) { c.middleware = append(c.middleware, fn) }
+}
+
+// WithRequestModifier calls fn on every outgoing request, including each
+// retry, after the SDK has set its headers, e.g. to sign the request or add
+// a header computed per request. req.GetBody returns a fresh copy of the
+// body. If fn returns an error the call fails with it, without retrying.
+// Multiple modifiers run in registration order.
+func WithRequestModifier(fn func(req *http.Request) error) ClientOption {
+	return func(c *clientConfig) { c.requestModifiers = append(c.requestModifiers, fn) }
+}
+
+// WithAPIKeyProvider fetches the API key dynamically, e.g. from a secrets
+// manager, so keys can rotate without rebuilding the client. The result is
+// cached for the TTL set by WithAPIKeyCacheTTL (default 5m). If the server
+// rejects a cached key with a 401, the provider is called again and the
+// request resent once with the new key. Provider errors are returned as
+// *AuthenticationError. Takes precedence over the static key.
+func WithAPIKeyProvider(fn func(ctx context.Context) (string, error)) ClientOption {
+	return func(c *clientConfig) { c.apiKeyProvider = fn }
+}
+
+// WithAPIKeyCacheTTL sets how long a key from WithAPIKeyProvider is reused (default 5m).
+func WithAPIKeyCacheTTL(d time.Duration) ClientOption {
+	return func(c *clientConfig) { c.apiKeyTTL = d }
+}
+
+// WithAuthHeader sets the header used to send the API key and the prefix
+// placed before it (default "Authorization" and "Bearer "). For example,
+// WithAuthHeader("X-API-Key", "") sends the bare key in X-API-Key.
+func WithAuthHeader(name, valuePrefix string) ClientOption {
+	return func(c *clientConfig) {
+		c.authHeader = name
+		c.authPrefix = valuePrefix
+	}
+}
+
+// WithDryRun disables all network I/O. Each request is serialized and handed
+// to sink (body is the JSON-encoded json.RawMessage, or nil), and the call
+// returns a zero-value result with no error. Useful for local development.
+func WithDryRun(sink func(method, path string, body any)) ClientOption {
+	return func(c *clientConfig) { c.dryRun = sink }
+}
+
+// WithClientValidation validates events locally (see Event.Validate) before
+// SendEvents or the Log* methods send them, after any WithEventInterceptor
+// changes, avoiding a server round trip for malformed batches.
+// Guardrail configs are likewise checked against their schemas before
+// CreateGuardrail and UpdateGuardrail (see CreateGuardrailParams.Validate).
+func WithClientValidation() ClientOption {
+	return func(c *clientConfig) { c.validate = true }
+}
+
+// WithAuditSigningKey makes VerifyAudit verify each report's HMAC-SHA256
+// signature with key, the server's AGENTLENS_AUDIT_SIGNING_KEY, returning
+// ErrInvalidSignature if it does not match or the report is unsigned.
+func WithAuditSigningKey(key string) ClientOption {
+	return func(c *clientConfig) { c.auditSigningKey = []byte(key) }
+}
+
+// WithUserAgent appends an application identifier (e.g. "my-agent/1.2") to
+// the default "agentlens-go/<Version>" User-Agent header.
+func WithUserAgent(s string) ClientOption {
+	return func(c *clientConfig) { c.userAgent = s }
+}
+
+// WithResponseCache keeps up to maxEntries GET responses that carry an ETag
+// and revalidates them with If-None-Match; a 304 Not Modified is answered
+// from the cache. Responses with Cache-Control: no-store are never cached.
+// Disabled by default.
+func WithResponseCache(maxEntries int) ClientOption {
+	return func(c *clientConfig) { c.cacheEntries = maxEntries }
+}
+
+// WithDefaultMetadata adds md to the metadata of every event sent by
+// SendEvents, LogLlmCall and StartLlmCall. Keys the event already sets are
+// not overwritten.
+func WithDefaultMetadata(md map[string]any) ClientOption {
+	return func(c *clientConfig) { c.defaultMetadata = md }
+}
+
+// WithMetadataInjector calls fn for every event sent by SendEvents,
+// LogLlmCall and StartLlmCall. fn receives a copy of the event whose Metadata
+// holds only the WithDefaultMetadata keys; whatever fn leaves in Metadata is
+// merged into the event. Precedence: keys the caller set on the event win,
+// then keys from fn, then defaults. fn must be safe for concurrent use.
+func WithMetadataInjector(fn func(*Event)) ClientOption {
+	return func(c *clientConfig) { c.metadataInjector = fn }
+}
+
+// WithEventInterceptor calls fn on every event the client sends, whether
+// logged directly, passed to SendEvents or queued by WithBatching, as the
+// last step before it is sent: after sampling, redaction and metadata from
+// WithDefaultMetadata and WithMetadataInjector. fn may modify the event,
+// e.g. to scrub or enrich it, or return false to drop it. Payload and
+// Metadata may be shared with the caller, so replace them instead of
+// changing them in place. fn must be safe for concurrent use.
+func WithEventInterceptor(fn func(e *Event) (keep bool)) ClientOption {
+	return func(c *clientConfig) { c.interceptor = fn }
+}
+
+// WithProxy sends requests through the proxy at proxyURL, e.g.
+// "http://proxy.corp:3128", instead of the one from HTTP_PROXY/HTTPS_PROXY.
+// It composes with WithTimeout and WithMiddleware but has no effect when
+// WithHTTPClient is set; configure that client's transport instead.
+func WithProxy(proxyURL string) ClientOption {
+	return func(c *clientConfig) { c.proxyURL = proxyURL }
+}
+
+// WithTLSConfig sets the TLS configuration for connections to the server,
+// e.g. to trust a self-signed certificate via RootCAs. The config is cloned.
+// Like WithProxy, it has no effect when WithHTTPClient is set.
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *clientConfig) { c.tlsConfig = cfg }
+}
+
+// WithConnectionPool tunes connection reuse: maxIdle idle connections in
+// total, maxIdlePerHost to the server (net/http keeps only 2 by default,
+// which causes connection churn under high-throughput BatchSender use),
+// at most maxConnsPerHost connections to it, and idleTimeout before an idle
+// connection is closed. Zero leaves a setting at the net/http default. Like
+// WithProxy, it has no effect when WithHTTPClient is set.
+func WithConnectionPool(maxIdle, maxIdlePerHost, maxConnsPerHost int, idleTimeout time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.connPool = &connPoolConfig{maxIdle, maxIdlePerHost, maxConnsPerHost, idleTimeout}
+	}
+}
+
+// WithEnvironment tags every request with an X-AgentLens-Env header, e.g.
+// "staging" or "prod", and adds it to event metadata as "environment"
+// (WithDefaultMetadata keys of the same name win).
+func WithEnvironment(env string) ClientOption {
+	return func(c *clientConfig) { c.environment = env }
+}
+
+// WithTenant tags every request with an X-Tenant-ID header for multi-tenant
+// deployments and adds it to event metadata as "tenantId"
+// (WithDefaultMetadata keys of the same name win).
+func WithTenant(id string) ClientOption {
+	return func(c *clientConfig) { c.tenantID = id }
+}
+
+// WithClientName tags every request with an X-App-Name header and adds name
+// to event metadata as "appName", attributing telemetry to one of several
+// apps sharing a service; with agent IDs this gives two-level attribution.
+// WithDefaultMetadata keys and event metadata of the same name win.
+func WithClientName(name string) ClientOption {
+	return func(c *clientConfig) { c.appName = name }
+}
+
+// WithRetryBudget limits retries across all of the client's calls, so that
+// during an outage retries can't multiply the load on a recovering server.
+// Each call adds ratio retries to a shared budget (e.g. 0.2 allows one retry
+// per five calls), minPerSec retries per second are allowed regardless of
+// traffic, and unused budget accumulates for up to 10 seconds. When the
+// budget is exhausted, a failing call returns its last error instead of
+// retrying. See Client.Stats.
+func WithRetryBudget(ratio float64, minPerSec int) ClientOption {
+	return func(c *clientConfig) { c.retryBudget = &retryBudgetConfig{ratio: ratio, minPerSec: minPerSec} }
+}
+
+// WithRetryPredicate replaces the default decision of which failures to
+// retry (see IsRetryable). fn receives the typed error, such as a
+// *ConnectionError, *RateLimitError, or a plain *APIError for statuses
+// without their own type, and the number of the attempt that failed,
+// starting at 1. It is not consulted once RetryConfig.MaxRetries is
+// reached. To extend rather than replace the default, fall back to
+// IsRetryable:
+//
+//	agentlens.WithRetryPredicate(func(err error, attempt int) bool {
+//		return agentlens.StatusCode(err) == 409 || agentlens.IsRetryable(err)
+//	})
+//
+// Cancelled contexts are never retried.
+func WithRetryPredicate(fn func(err error, attempt int) bool) ClientOption {
+	return func(c *clientConfig) { c.retryPredicate = fn }
+}
+
+// WithHedging cuts tail latency of reads: when a GET has not been answered
+// within after, the same request is sent again, up to max requests in
+// flight in total, and the first response wins while the others are
+// cancelled. Only GETs are hedged, so writes are never duplicated. Each
+// hedge is withdrawn from the WithRetryBudget budget, if set, and is
+// skipped when the budget is exhausted. Hedges are counted in
+// Client.Stats. max < 2 disables hedging.
+func WithHedging(after time.Duration, max int) ClientOption {
+	return func(c *clientConfig) { c.hedging = &hedgingConfig{after: after, max: max} }
+}
+
+// WithRateLimit limits the client to rps requests per second, allowing
+// bursts of up to burst requests, so it stays under server quotas instead of
+// spending retries on 429s. Each attempt, retries included, waits for its
+// turn; a call whose ctx ends while waiting fails with a *ConnectionError.
+// Waits are counted in Client.Stats. rps <= 0 disables the limit.
+//
+// The limiter is a small token bucket of its own rather than
+// golang.org/x/time/rate, which would be the module's first dependency; it
+// behaves like rate.Limiter's Wait, reserving a token and returning it if
+// ctx ends first.
+func WithRateLimit(rps float64, burst int) ClientOption {
+	return func(c *clientConfig) {
+		skip := c.rateLimit != nil && c.rateLimit.skipHealth
+		c.rateLimit = &rateLimitConfig{rps: rps, burst: burst, skipHealth: skip}
+	}
+}
+
+// WithRateLimitHealthBypass exempts Health checks from WithRateLimit, so
+// liveness probes are not delayed behind queued traffic.
+func WithRateLimitHealthBypass() ClientOption {
+	return func(c *clientConfig) {
+		if c.rateLimit == nil {
+			c.rateLimit = &rateLimitConfig{}
+		}
+		c.rateLimit.skipHealth = true
+	}
+}
+
+// WithMaxConcurrentRequests allows at most n requests in flight at once
+// across all goroutines sharing the client. Further attempts, retries
+// included, wait for a free slot; a call whose ctx ends while waiting fails
+// with a *ConnectionError. Hedged copies of a request share its slot.
+// Client.Stats reports the current number in flight. n <= 0 (the default)
+// is unlimited.
+func WithMaxConcurrentRequests(n int) ClientOption {
+	return func(c *clientConfig) { c.maxInFlight = n }
+}
+
+// WithMaxResponseBytes caps how much of a response body is read (default
+// 32MB). A larger body fails the call with an error wrapping
+// ErrResponseTooLarge instead of being buffered in memory. n <= 0 removes
+// the limit.
+func WithMaxResponseBytes(n int64) ClientOption {
+	return func(c *clientConfig) { c.maxResponseBytes = n }
+}
+
+// WithStrictDecoding makes responses fail to decode when the server sends a
+// field the SDK's types don't declare, to catch server/SDK version drift in
+// staging. The default lenient decoding ignores unknown fields, so newer
+// servers keep working with older clients. Types with their own JSON
+// decoding, such as RecallResult and HealthSnapshot, stay lenient.
+func WithStrictDecoding() ClientOption {
+	return func(c *clientConfig) { c.strictDecoding = true }
+}
+
+// WithBatching gives the client an internal BatchSender, configured with
+// opts and created on first use. LogLlmCall, LogToolCall, LogEvent and
+// LogError then queue their events instead of sending them immediately.
+// Call Client.Close to flush the queue before exiting.
+func WithBatching(opts ...BatchOption) ClientOption {
+	return func(c *clientConfig) {
+		c.batching = true
+		c.batchOpts = opts
+	}
+}
+
+// WithReplayOnStart replays the disk buffer files left by an earlier
+// process, e.g. after a quota outage, when the client is created: their
+// events are queued on the client's BatchSender and each file is deleted
+// once queued (see BatchSender.ReplayBufferedFiles). The replay runs in the
+// background, so NewClient does not wait for it; files that can't be
+// replayed are reported via WithBatchOnError, and the replayed batches
+// reach WithOnFlush as they are sent. It has no effect without WithBatching.
+func WithReplayOnStart() ClientOption {
+	return func(c *clientConfig) { c.replayOnStart = true }
+}
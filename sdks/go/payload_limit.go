@@ -0,0 +1,130 @@
+package agentlens
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// TruncateMode selects what WithMaxPayloadBytes does with an oversized
+// event payload.
+type TruncateMode int
+
+const (
+	// TruncateModeReject drops the event and reports it to the WithFailOpen
+	// error callback.
+	TruncateModeReject TruncateMode = iota
+	// TruncateModeTruncate shortens the payload's longest strings until it
+	// fits and sets "_truncated": true in it. An event that cannot be made
+	// to fit this way is dropped as in TruncateModeReject.
+	TruncateModeTruncate
+)
+
+type payloadLimit struct {
+	maxBytes int
+	mode     TruncateMode
+}
+
+// WithMaxPayloadBytes limits the JSON-encoded size of each event's payload
+// to n bytes, so one oversized tool result can't fail a whole batch. It
+// applies to SendEvents and to events queued by WithBatching; mode chooses
+// between dropping (TruncateModeReject) and shortening
+// (TruncateModeTruncate) oversized payloads. The server itself replaces
+// payloads over 10KB with a short preview.
+func WithMaxPayloadBytes(n int, mode TruncateMode) ClientOption {
+	return func(c *clientConfig) { c.payloadLimit = &payloadLimit{maxBytes: n, mode: mode} }
+}
+
+// fit returns e with its payload within the limit, or an error if it can't
+// be sent. The caller's payload map is not modified.
+func (l *payloadLimit) fit(e Event) (Event, error) {
+	size := payloadSize(e.Payload)
+	if size <= l.maxBytes {
+		return e, nil
+	}
+	if l.mode == TruncateModeTruncate {
+		if p, ok := truncatePayload(e.Payload, l.maxBytes); ok {
+			e.Payload = p
+			return e, nil
+		}
+	}
+	return e, newFieldValidationError("payload",
+		fmt.Sprintf("%d bytes exceeds the %d byte limit (event %s, session %s)", size, l.maxBytes, e.EventType, e.SessionID))
+}
+
+func payloadSize(p map[string]any) int {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return 0 // reported when the batch is sent
+	}
+	return len(data)
+}
+
+// truncationSuffix is appended to every shortened string.
+const truncationSuffix = "…[truncated]"
+
+// truncatePayload returns a copy of p with its longest strings shortened so
+// that it encodes to at most maxBytes, marked with "_truncated": true.
+func truncatePayload(p map[string]any, maxBytes int) (map[string]any, bool) {
+	// Round-trip through JSON so strings inside typed values (e.g. a
+	// []LlmMessage) are reachable, and the caller's maps are left alone.
+	var out map[string]any
+	if remarshal(p, &out) != nil {
+		return nil, false
+	}
+	out["_truncated"] = true
+
+	// Collect the payload's strings, each with a setter to replace it.
+	type leaf struct {
+		s   string
+		set func(string)
+	}
+	var leaves []leaf
+	var walk func(v any, set func(string))
+	walk = func(v any, set func(string)) {
+		switch v := v.(type) {
+		case string:
+			leaves = append(leaves, leaf{v, set})
+		case map[string]any:
+			for k, child := range v {
+				k := k
+				walk(child, func(s string) { v[k] = s })
+			}
+		case []any:
+			for i, child := range v {
+				i := i
+				walk(child, func(s string) { v[i] = s })
+			}
+		}
+	}
+	walk(out, nil)
+	sort.Slice(leaves, func(i, j int) bool { return len(leaves[i].s) > len(leaves[j].s) })
+
+	// Shorten the longest strings first. Escaping can make a string encode
+	// larger than its length, so each is cut again until the payload fits
+	// or nothing of it is left.
+	for _, l := range leaves {
+		if len(l.s) <= len(truncationSuffix) {
+			break // shortening the rest would only lengthen them
+		}
+		kept := l.s
+		for kept != "" {
+			excess := payloadSize(out) - maxBytes
+			if excess <= 0 {
+				return out, true
+			}
+			keep := len(kept) - excess
+			if len(kept) == len(l.s) {
+				keep -= len(truncationSuffix) // the first cut adds the suffix
+			}
+			keep = min(max(keep, 0), len(kept)-1)
+			for keep > 0 && !utf8.RuneStart(kept[keep]) {
+				keep--
+			}
+			kept = kept[:keep]
+			l.set(kept + truncationSuffix)
+		}
+	}
+	return out, payloadSize(out) <= maxBytes
+}
@@ -0,0 +1,76 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxPayloadBytes(t *testing.T) {
+	var sent []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, body.Events...)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	big := map[string]any{
+		"toolName": "fetch",
+		"result":   strings.Repeat("x", 5000),
+		"nested":   map[string]any{"log": strings.Repeat("é", 2000)},
+	}
+	events := []Event{
+		{SessionID: "s1", AgentID: "a1", EventType: "tool_response", Payload: big},
+		{SessionID: "s1", AgentID: "a1", EventType: "custom", Payload: map[string]any{"ok": true}},
+	}
+
+	t.Run("truncate", func(t *testing.T) {
+		sent = nil
+		c := NewClient(srv.URL, "key", WithMaxPayloadBytes(1024, TruncateModeTruncate))
+		if err := c.SendEvents(context.Background(), events); err != nil {
+			t.Fatal(err)
+		}
+		if len(sent) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(sent))
+		}
+		p := sent[0].Payload
+		if size := payloadSize(p); size > 1024 {
+			t.Errorf("payload is %d bytes, want at most 1024", size)
+		}
+		if p["_truncated"] != true || p["toolName"] != "fetch" || !strings.HasSuffix(p["result"].(string), truncationSuffix) {
+			t.Errorf("unexpected truncated payload: %v", p)
+		}
+		if len(big["result"].(string)) != 5000 {
+			t.Error("the caller's payload must not be modified")
+		}
+		if _, ok := sent[1].Payload["_truncated"]; ok {
+			t.Error("small payloads should be left alone")
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		sent = nil
+		var reported error
+		c := NewClient(srv.URL, "key",
+			WithMaxPayloadBytes(1024, TruncateModeReject),
+			WithFailOpen(func(err error) { reported = err }))
+		if err := c.SendEvents(context.Background(), events); err != nil {
+			t.Fatal(err)
+		}
+		if len(sent) != 1 || sent[0].EventType != "custom" {
+			t.Fatalf("expected only the small event to be sent, got %+v", sent)
+		}
+		var ve *ValidationError
+		if !errors.As(reported, &ve) || !strings.Contains(ve.Message, "1024 byte limit") {
+			t.Errorf("expected the dropped event to be reported, got %v", reported)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package agentlens
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ModelPricing holds per-token USD rates for a model.
+type ModelPricing struct {
+	InputPerToken  float64
+	OutputPerToken float64
+}
+
+// perMillion converts per-million-token prices to a ModelPricing.
+func perMillion(input, output float64) ModelPricing {
+	return ModelPricing{InputPerToken: input / 1e6, OutputPerToken: output / 1e6}
+}
+
+// PricingTable maps provider+model to token prices. Lookups match the exact
+// model name first and then the longest registered name followed by "-", so
+// dated model variants (e.g. "gpt-4o-2024-08-06") resolve to their base
+// entry while distinct models such as "gpt-4.1" are not priced as "gpt-4".
+// Safe for concurrent use.
+type PricingTable struct {
+	mu     sync.RWMutex
+	prices map[string]map[string]ModelPricing
+}
+
+// NewPricingTable creates an empty PricingTable.
+func NewPricingTable() *PricingTable {
+	return &PricingTable{prices: make(map[string]map[string]ModelPricing)}
+}
+
+// DefaultPricingTable returns a new PricingTable populated with list prices
+// for common OpenAI and Anthropic models. Entries can be overridden with Set.
+func DefaultPricingTable() *PricingTable {
+	pt := NewPricingTable()
+	for model, p := range map[string]ModelPricing{
+		"gpt-4o":        perMillion(2.50, 10.00),
+		"gpt-4o-mini":   perMillion(0.15, 0.60),
+		"gpt-4-turbo":   perMillion(10.00, 30.00),
+		"gpt-4":         perMillion(30.00, 60.00),
+		"gpt-3.5-turbo": perMillion(0.50, 1.50),
+		"o1":            perMillion(15.00, 60.00),
+		"o1-mini":       perMillion(3.00, 12.00),
+	} {
+		pt.Set("openai", model, p)
+	}
+	for model, p := range map[string]ModelPricing{
+		"claude-3-5-sonnet": perMillion(3.00, 15.00),
+		"claude-3-5-haiku":  perMillion(0.80, 4.00),
+		"claude-3-opus":     perMillion(15.00, 75.00),
+		"claude-3-sonnet":   perMillion(3.00, 15.00),
+		"claude-3-haiku":    perMillion(0.25, 1.25),
+	} {
+		pt.Set("anthropic", model, p)
+	}
+	return pt
+}
+
+// Set adds or replaces the pricing for a provider and model.
+func (pt *PricingTable) Set(provider, model string, p ModelPricing) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	provider = strings.ToLower(provider)
+	if pt.prices[provider] == nil {
+		pt.prices[provider] = make(map[string]ModelPricing)
+	}
+	pt.prices[provider][strings.ToLower(model)] = p
+}
+
+// Lookup returns the pricing for a provider and model, if known.
+func (pt *PricingTable) Lookup(provider, model string) (ModelPricing, bool) {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+	models := pt.prices[strings.ToLower(provider)]
+	model = strings.ToLower(model)
+	if p, ok := models[model]; ok {
+		return p, true
+	}
+	var best string
+	for name := range models {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return ModelPricing{}, false
+	}
+	return models[best], true
+}
+
+// EstimateCost returns the USD cost of usage for the given provider and model,
+// or zero if the model is not in the table.
+func (pt *PricingTable) EstimateCost(provider, model string, usage LlmUsage) float64 {
+	p, ok := pt.Lookup(provider, model)
+	if !ok {
+		return 0
+	}
+	return float64(usage.InputTokens)*p.InputPerToken + float64(usage.OutputTokens)*p.OutputPerToken
+}
+
+// fillCost returns params.CostUsd, or an estimate from the client's pricing
+// table when the caller left it zero. Unknown models are reported via onError.
+func (c *Client) fillCost(params *LogLlmCallParams) float64 {
+	if params.CostUsd != 0 || c.cfg.pricing == nil {
+		return params.CostUsd
+	}
+	if _, ok := c.cfg.pricing.Lookup(params.Provider, params.Model); !ok {
+		if c.cfg.onError != nil {
+			c.cfg.onError(fmt.Errorf("agentlens: no pricing for %s/%s, costUsd left at zero", params.Provider, params.Model))
+		}
+		return 0
+	}
+	return c.cfg.pricing.EstimateCost(params.Provider, params.Model, params.Usage)
+}
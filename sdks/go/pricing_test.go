@@ -0,0 +1,91 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPricingTableEstimateCost(t *testing.T) {
+	pt := DefaultPricingTable()
+	usage := LlmUsage{InputTokens: 1000, OutputTokens: 500}
+
+	got := pt.EstimateCost("openai", "gpt-4o-2024-08-06", usage)
+	want := 1000*2.50/1e6 + 500*10.00/1e6
+	if math.Abs(got-want) > 1e-12 {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// gpt-4o-mini must not resolve to the shorter gpt-4o prefix
+	got = pt.EstimateCost("openai", "gpt-4o-mini", usage)
+	want = 1000*0.15/1e6 + 500*0.60/1e6
+	if math.Abs(got-want) > 1e-12 {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	got = pt.EstimateCost("openai", "gpt-4-0613", usage)
+	want = 1000*30.00/1e6 + 500*60.00/1e6
+	if math.Abs(got-want) > 1e-12 {
+		t.Errorf("expected gpt-4-0613 to fall back to gpt-4, got %v", got)
+	}
+
+	// Newer models sharing a base name without a "-" suffix are unknown.
+	for _, model := range []string{"gpt-4.1", "gpt-4o1", "o1x"} {
+		if _, ok := pt.Lookup("openai", model); ok {
+			t.Errorf("expected no pricing for %s", model)
+		}
+	}
+
+	pt.Set("openai", "gpt-4o", ModelPricing{InputPerToken: 1, OutputPerToken: 2})
+	if got := pt.EstimateCost("openai", "gpt-4o", usage); got != 2000 {
+		t.Errorf("expected override to apply, got %v", got)
+	}
+
+	if got := pt.EstimateCost("acme", "unknown", usage); got != 0 {
+		t.Errorf("expected 0 for unknown model, got %v", got)
+	}
+}
+
+func TestLogLlmCallAutoCost(t *testing.T) {
+	var costs []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []struct {
+				Payload map[string]any `json:"payload"`
+			} `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		costs = append(costs, body.Events[1].Payload["costUsd"])
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	var reported error
+	pt := NewPricingTable()
+	pt.Set("openai", "gpt-4", ModelPricing{InputPerToken: 0.01, OutputPerToken: 0.02})
+	c := NewClient(srv.URL, "key", WithPricingTable(pt), WithFailOpen(func(err error) { reported = err }))
+
+	usage := LlmUsage{InputTokens: 10, OutputTokens: 5}
+	c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{Provider: "openai", Model: "gpt-4", Usage: usage})
+	c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{Provider: "openai", Model: "gpt-4", Usage: usage, CostUsd: 7})
+	c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{Provider: "acme", Model: "x", Usage: usage})
+
+	if len(costs) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(costs))
+	}
+	if math.Abs(costs[0].(float64)-0.2) > 1e-12 {
+		t.Errorf("expected auto-filled cost 0.2, got %v", costs[0])
+	}
+	if costs[1].(float64) != 7 {
+		t.Errorf("explicit cost should win, got %v", costs[1])
+	}
+	if costs[2].(float64) != 0 {
+		t.Errorf("unknown model should leave cost at zero, got %v", costs[2])
+	}
+	if reported == nil {
+		t.Error("expected onError for unknown model")
+	}
+}
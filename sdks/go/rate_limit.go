@@ -0,0 +1,88 @@
+package agentlens
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rateLimiter is a token bucket holding up to burst tokens and refilled at
+// rps tokens per second. Waiters reserve a token up front, possibly driving
+// the balance negative, so concurrent callers are served in arrival order.
+type rateLimiter struct {
+	rps   float64
+	burst float64
+	clock clock
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	delayed   atomic.Int64
+	waitNanos atomic.Int64
+}
+
+func newRateLimiter(rps float64, burst int, clk clock) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rps: rps, burst: float64(burst), clock: clk, tokens: float64(burst), last: clk.Now()}
+}
+
+// wait blocks until a request may be sent or ctx ends. A token reserved by
+// a cancelled wait is returned to the bucket.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := l.clock.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rps)
+	l.last = now
+	l.tokens--
+	deficit := -l.tokens
+	l.mu.Unlock()
+	if deficit <= 0 {
+		return nil
+	}
+
+	delay := time.Duration(deficit / l.rps * float64(time.Second))
+	l.delayed.Add(1)
+	l.waitNanos.Add(int64(delay))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// requestSlots counts the requests in flight and, with slots set by
+// WithMaxConcurrentRequests, caps them.
+type requestSlots struct {
+	slots chan struct{}
+	count atomic.Int64
+}
+
+// acquire blocks until a request may start or ctx ends.
+func (s *requestSlots) acquire(ctx context.Context) error {
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	s.count.Add(1)
+	return nil
+}
+
+func (s *requestSlots) release() {
+	s.count.Add(-1)
+	if s.slots != nil {
+		<-s.slots
+	}
+}
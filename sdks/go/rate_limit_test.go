@@ -0,0 +1,96 @@
+package agentlens
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := NewClient(srv.URL, "key", WithRateLimit(20, 1))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetAgent(ctx, "a1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected 3 requests at 20/s to take ~100ms, took %v", elapsed)
+	}
+	if st := c.Stats(); st.RequestsRateLimited != 2 || st.RateLimitWait <= 0 {
+		t.Errorf("expected 2 delayed requests, got %+v", st)
+	}
+
+	c = NewClient(srv.URL, "key", WithRateLimit(0.1, 1), WithRateLimitHealthBypass())
+	if _, err := c.GetAgent(ctx, "a1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Health(ctx); err != nil {
+		t.Fatalf("health check should bypass the limiter: %v", err)
+	}
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err := c.GetAgent(short, "a1")
+	var connErr *ConnectionError
+	if !errors.As(err, &connErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected ConnectionError wrapping DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var active, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+		active.Add(-1)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := NewClient(srv.URL, "key", WithMaxConcurrentRequests(3))
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetAgent(ctx, "a1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := c.Stats().RequestsInFlight; n < 1 || n > 3 {
+		t.Errorf("expected 1-3 requests in flight, got %d", n)
+	}
+	wg.Wait()
+	if p := peak.Load(); p > 3 {
+		t.Errorf("expected at most 3 concurrent requests, saw %d", p)
+	}
+	if n := c.Stats().RequestsInFlight; n != 0 {
+		t.Errorf("expected no requests in flight after completion, got %d", n)
+	}
+
+	// A call waiting for a slot gives up when its ctx ends.
+	c = NewClient(srv.URL, "key", WithMaxConcurrentRequests(1))
+	c.inFlight.slots <- struct{}{}
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	var connErr *ConnectionError
+	if _, err := c.GetAgent(short, "a1"); !errors.As(err, &connErr) {
+		t.Errorf("expected ConnectionError while waiting for a slot, got %v", err)
+	}
+}
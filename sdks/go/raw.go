@@ -0,0 +1,70 @@
+package agentlens
+
+import "encoding/json"
+
+// The UnmarshalJSON methods below decode into the typed fields and keep a
+// copy of the input in Raw, so fields added by newer servers stay reachable.
+
+func (m *RecallMatch) UnmarshalJSON(data []byte) error {
+	type plain RecallMatch
+	if err := json.Unmarshal(data, (*plain)(m)); err != nil {
+		return err
+	}
+	m.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (r *RecallResult) UnmarshalJSON(data []byte) error {
+	type plain RecallResult
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	r.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (i *ReflectInsight) UnmarshalJSON(data []byte) error {
+	type plain ReflectInsight
+	if err := json.Unmarshal(data, (*plain)(i)); err != nil {
+		return err
+	}
+	i.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (r *ReflectResult) UnmarshalJSON(data []byte) error {
+	type plain ReflectResult
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	r.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (s *ContextSession) UnmarshalJSON(data []byte) error {
+	type plain ContextSession
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+	s.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (l *ContextLesson) UnmarshalJSON(data []byte) error {
+	type plain ContextLesson
+	if err := json.Unmarshal(data, (*plain)(l)); err != nil {
+		return err
+	}
+	l.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (r *ContextResult) UnmarshalJSON(data []byte) error {
+	type plain ContextResult
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	r.Raw = append(json.RawMessage(nil), data...)
+	r.Entries = contextEntries(r)
+	return nil
+}
@@ -0,0 +1,75 @@
+package agentlens
+
+import "sort"
+
+// RecallScope selects the sources Recall searches. These are the values the
+// server accepts; any other scope matches nothing.
+type RecallScope string
+
+const (
+	// RecallScopeAll searches events and sessions (the server default).
+	RecallScopeAll RecallScope = "all"
+	// RecallScopeEvents searches individual events.
+	RecallScopeEvents RecallScope = "events"
+	// RecallScopeSessions searches session summaries.
+	RecallScopeSessions RecallScope = "sessions"
+)
+
+// RecallOption configures a query built by NewRecallQuery.
+type RecallOption func(*RecallQuery)
+
+// NewRecallQuery returns a RecallQuery for query with opts applied.
+func NewRecallQuery(query string, opts ...RecallOption) *RecallQuery {
+	q := &RecallQuery{Query: query}
+	for _, o := range opts {
+		o(q)
+	}
+	return q
+}
+
+// WithScope sets the recall scope.
+func WithScope(scope RecallScope) RecallOption {
+	return func(q *RecallQuery) { q.Scope = scope }
+}
+
+// WithMinScore sets the minimum similarity score, between 0 and 1.
+func WithMinScore(score float64) RecallOption {
+	return func(q *RecallQuery) { q.MinScore = &score }
+}
+
+// WithLocalMinScore drops results below score client-side as well as
+// asking the server to; see RecallQuery.EnforceMinScore.
+func WithLocalMinScore(score float64) RecallOption {
+	return func(q *RecallQuery) {
+		q.MinScore = &score
+		q.EnforceMinScore = true
+	}
+}
+
+// WithSortByScore sorts results by score, highest first.
+func WithSortByScore() RecallOption {
+	return func(q *RecallQuery) { q.SortByScore = true }
+}
+
+// WithLimit sets the maximum number of results.
+func WithLimit(n int) RecallOption {
+	return func(q *RecallQuery) { q.Limit = &n }
+}
+
+// refine applies the client-side EnforceMinScore and SortByScore options
+// to r. TotalResults is reduced by the number of results dropped.
+func (q *RecallQuery) refine(r *RecallResult) {
+	if q.EnforceMinScore && q.MinScore != nil {
+		kept := r.Results[:0]
+		for _, m := range r.Results {
+			if m.Score >= *q.MinScore {
+				kept = append(kept, m)
+			}
+		}
+		r.TotalResults -= len(r.Results) - len(kept)
+		r.Results = kept
+	}
+	if q.SortByScore {
+		sort.SliceStable(r.Results, func(i, j int) bool { return r.Results[i].Score > r.Results[j].Score })
+	}
+}
@@ -0,0 +1,69 @@
+package agentlens
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewRecallQuery(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.RawQuery
+		w.Write([]byte(`{"results":[],"query":"timeouts","totalResults":0}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	q := NewRecallQuery("timeouts", WithScope(RecallScopeSessions), WithMinScore(0.5), WithLimit(5))
+	if _, err := c.Recall(context.Background(), q); err != nil {
+		t.Fatal(err)
+	}
+	if got != "limit=5&minScore=0.5&query=timeouts&scope=sessions" {
+		t.Errorf("unexpected query string: %s", got)
+	}
+
+	got = ""
+	_, err := c.Recall(context.Background(), NewRecallQuery("timeouts", WithMinScore(1.5)))
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if got != "" {
+		t.Error("expected no request for an invalid MinScore")
+	}
+}
+
+func TestRecallLocalMinScoreAndSort(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"query":"q","totalResults":4,"results":[
+			{"sourceType":"event","sourceId":"a","score":0.6},
+			{"sourceType":"event","sourceId":"b","score":0.2},
+			{"sourceType":"event","sourceId":"c","score":0.9},
+			{"sourceType":"event","sourceId":"d","score":0.7}]}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	res, err := c.Recall(context.Background(), NewRecallQuery("q", WithLocalMinScore(0.5), WithSortByScore()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids string
+	for _, m := range res.Results {
+		ids += m.SourceID
+	}
+	if ids != "cda" || res.TotalResults != 3 {
+		t.Errorf("expected c,d,a with total 3, got %q total %d", ids, res.TotalResults)
+	}
+
+	res, err = c.Recall(context.Background(), NewRecallQuery("q", WithMinScore(0.5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results) != 4 {
+		t.Errorf("WithMinScore alone should leave filtering to the server, got %d results", len(res.Results))
+	}
+}
@@ -0,0 +1,195 @@
+package agentlens
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Analysis types accepted by Reflect (ReflectQuery.Analysis). These are
+// the only analyses the server runs; any other, such as
+// "anomaly_detection", is rejected with a 400.
+const (
+	AnalysisErrorPatterns     = "error_patterns"
+	AnalysisToolSequences     = "tool_sequences"
+	AnalysisCostAnalysis      = "cost_analysis"
+	AnalysisPerformanceTrends = "performance_trends"
+)
+
+// Insight types (ReflectInsight.Type) produced by each analysis.
+const (
+	InsightErrorPattern          = "error_pattern"
+	InsightToolSequence          = "tool_sequence"
+	InsightErrorProneSequence    = "error_prone_sequence"
+	InsightCostSummary           = "cost_summary"
+	InsightCostTrend             = "cost_trend"
+	InsightCostByModel           = "cost_by_model"
+	InsightPerformanceCurrent    = "performance_current"
+	InsightPerformanceAssessment = "performance_assessment"
+)
+
+// ErrorPattern is the data of an error_pattern insight.
+type ErrorPattern struct {
+	Pattern          string     `json:"pattern"`
+	Count            int        `json:"count"`
+	FirstSeen        string     `json:"firstSeen"`
+	LastSeen         string     `json:"lastSeen"`
+	AffectedSessions []string   `json:"affectedSessions"`
+	PrecedingTools   [][]string `json:"precedingTools"`
+}
+
+// ToolSequence is the data of a tool_sequence or error_prone_sequence
+// insight.
+type ToolSequence struct {
+	Tools     []string `json:"tools"`
+	Frequency int      `json:"frequency"`
+	Sessions  int      `json:"sessions"`
+	// ErrorRate is 0-1; non-zero for error_prone_sequence insights.
+	ErrorRate float64 `json:"errorRate"`
+}
+
+// CostSummary is the data of a cost_summary insight.
+type CostSummary struct {
+	TotalCost     float64 `json:"totalCost"`
+	AvgPerSession float64 `json:"avgPerSession"`
+	TotalSessions int     `json:"totalSessions"`
+}
+
+// CostTrend is the data of a cost_trend insight.
+type CostTrend struct {
+	// Direction is "increasing", "stable" or "decreasing".
+	Direction   string `json:"direction"`
+	BucketCount int    `json:"bucketCount"`
+}
+
+// ModelCost is the data of a cost_by_model insight.
+type ModelCost struct {
+	Model          string  `json:"model"`
+	TotalCost      float64 `json:"totalCost"`
+	CallCount      int     `json:"callCount"`
+	AvgCostPerCall float64 `json:"avgCostPerCall"`
+}
+
+// PerformanceSnapshot is the data of a performance_current insight.
+type PerformanceSnapshot struct {
+	// SuccessRate is 0-1.
+	SuccessRate  float64 `json:"successRate"`
+	AvgDuration  float64 `json:"avgDuration"`
+	AvgToolCalls float64 `json:"avgToolCalls"`
+	AvgErrors    float64 `json:"avgErrors"`
+}
+
+// ErrorPatternsReport is the typed form of an error_patterns analysis.
+type ErrorPatternsReport struct {
+	Patterns []ErrorPattern
+}
+
+// ToolSequencesReport is the typed form of a tool_sequences analysis.
+type ToolSequencesReport struct {
+	Sequences []ToolSequence
+}
+
+// CostAnalysisReport is the typed form of a cost_analysis analysis. ByModel
+// holds the most expensive models, at most three.
+type CostAnalysisReport struct {
+	Summary CostSummary
+	Trend   CostTrend
+	ByModel []ModelCost
+}
+
+// PerformanceTrendsReport is the typed form of a performance_trends
+// analysis.
+type PerformanceTrendsReport struct {
+	Current PerformanceSnapshot
+	// Assessment is "improving", "stable" or "degrading".
+	Assessment string
+}
+
+// As decodes the insight's Data into target, e.g. a *ErrorPattern for an
+// error_pattern insight.
+func (i *ReflectInsight) As(target any) error {
+	return remarshal(i.Data, target)
+}
+
+// As decodes the result into target. The report types
+// (*ErrorPatternsReport, *ToolSequencesReport, *CostAnalysisReport,
+// *PerformanceTrendsReport) are filled from the insights of the matching
+// analysis; using one with a different analysis is an error. Any other
+// target is decoded from the raw response.
+//
+//	var report agentlens.CostAnalysisReport
+//	if err := result.As(&report); err != nil { ... }
+func (r *ReflectResult) As(target any) error {
+	want := ""
+	switch target.(type) {
+	case *ErrorPatternsReport:
+		want = AnalysisErrorPatterns
+	case *ToolSequencesReport:
+		want = AnalysisToolSequences
+	case *CostAnalysisReport:
+		want = AnalysisCostAnalysis
+	case *PerformanceTrendsReport:
+		want = AnalysisPerformanceTrends
+	default:
+		if r.Raw != nil {
+			return json.Unmarshal(r.Raw, target)
+		}
+		return remarshal(r, target)
+	}
+	if r.Analysis != want {
+		return fmt.Errorf("agentlens: cannot decode %q analysis into %T", r.Analysis, target)
+	}
+
+	for i := range r.Insights {
+		in := &r.Insights[i]
+		var err error
+		switch t := target.(type) {
+		case *ErrorPatternsReport:
+			if in.Type == InsightErrorPattern {
+				var p ErrorPattern
+				err = in.As(&p)
+				t.Patterns = append(t.Patterns, p)
+			}
+		case *ToolSequencesReport:
+			if in.Type == InsightToolSequence || in.Type == InsightErrorProneSequence {
+				var s ToolSequence
+				err = in.As(&s)
+				t.Sequences = append(t.Sequences, s)
+			}
+		case *CostAnalysisReport:
+			switch in.Type {
+			case InsightCostSummary:
+				err = in.As(&t.Summary)
+			case InsightCostTrend:
+				err = in.As(&t.Trend)
+			case InsightCostByModel:
+				var m ModelCost
+				err = in.As(&m)
+				t.ByModel = append(t.ByModel, m)
+			}
+		case *PerformanceTrendsReport:
+			switch in.Type {
+			case InsightPerformanceCurrent:
+				err = in.As(&t.Current)
+			case InsightPerformanceAssessment:
+				var a struct {
+					Assessment string `json:"assessment"`
+				}
+				err = in.As(&a)
+				t.Assessment = a.Assessment
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("agentlens: decode %s insight: %w", in.Type, err)
+		}
+	}
+	return nil
+}
+
+// remarshal converts src to target through JSON.
+func remarshal(src, target any) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
@@ -0,0 +1,49 @@
+package agentlens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReflectResultAs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"analysis":"cost_analysis","insights":[
+			{"type":"cost_summary","summary":"","data":{"totalCost":12.5,"avgPerSession":2.5,"totalSessions":5},"confidence":1},
+			{"type":"cost_trend","summary":"","data":{"direction":"increasing","bucketCount":7},"confidence":0.8},
+			{"type":"cost_by_model","summary":"","data":{"model":"gpt-4o","totalCost":10,"callCount":4,"avgCostPerCall":2.5},"confidence":0.9}
+		],"metadata":{"sessionsAnalyzed":5,"eventsAnalyzed":40,"timeRange":{"from":"","to":""}}}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	res, err := c.Reflect(context.Background(), &ReflectQuery{Analysis: AnalysisCostAnalysis})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report CostAnalysisReport
+	if err := res.As(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Summary.TotalCost != 12.5 || report.Trend.Direction != "increasing" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.ByModel) != 1 || report.ByModel[0].Model != "gpt-4o" || report.ByModel[0].CallCount != 4 {
+		t.Errorf("unexpected ByModel: %+v", report.ByModel)
+	}
+
+	var patterns ErrorPatternsReport
+	if err := res.As(&patterns); err == nil {
+		t.Error("expected an error decoding cost_analysis into ErrorPatternsReport")
+	}
+
+	var raw struct {
+		Metadata struct {
+			EventsAnalyzed int `json:"eventsAnalyzed"`
+		} `json:"metadata"`
+	}
+	if err := res.As(&raw); err != nil || raw.Metadata.EventsAnalyzed != 40 {
+		t.Errorf("raw decode: %+v, %v", raw, err)
+	}
+}
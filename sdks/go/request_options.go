@@ -0,0 +1,96 @@
+package agentlens
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestOption overrides client configuration for the calls of a client
+// returned by Client.WithOptions.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	timeout time.Duration
+	retry   *RetryConfig
+	headers map[string]string
+}
+
+// PerRequestTimeout replaces the client's WithTimeout. As with WithTimeout,
+// it bounds each attempt, not a call's retries as a whole.
+func PerRequestTimeout(d time.Duration) RequestOption {
+	return func(o *requestOptions) { o.timeout = d }
+}
+
+// PerRequestRetry replaces the client's WithRetry settings, e.g.
+// RetryConfig{MaxRetries: 0} to fail fast.
+func PerRequestRetry(cfg RetryConfig) RequestOption {
+	return func(o *requestOptions) { o.retry = &cfg }
+}
+
+// ExtraHeaders adds headers to each request. They are set after the SDK's
+// own headers, so they can override them.
+func ExtraHeaders(h map[string]string) RequestOption {
+	return func(o *requestOptions) {
+		if o.headers == nil {
+			o.headers = make(map[string]string, len(h))
+		}
+		for k, v := range h {
+			o.headers[k] = v
+		}
+	}
+}
+
+// WithOptions returns a client that applies opts, on top of any options of
+// c, to the calls made through it:
+//
+//	health, err := client.WithOptions(agentlens.PerRequestTimeout(2*time.Second)).Health(ctx)
+//
+// It is cheap to create per call. The returned client shares everything
+// else with c, including its batching sender, caches and Close.
+func (c *Client) WithOptions(opts ...RequestOption) *Client {
+	var o requestOptions
+	if c.opts != nil {
+		o = *c.opts
+		if c.opts.headers != nil {
+			o.headers = make(map[string]string, len(c.opts.headers))
+			for k, v := range c.opts.headers {
+				o.headers[k] = v
+			}
+		}
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Client{clientState: c.clientState, opts: &o}
+}
+
+// idempotencyKeyHeader lets the server recognize a retried write.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// withIdempotencyKey returns c with key as the Idempotency-Key header of
+// its calls, unless the caller already set one with ExtraHeaders.
+func (c *Client) withIdempotencyKey(key string) *Client {
+	if c.opts != nil && c.opts.headers[idempotencyKeyHeader] != "" {
+		return c
+	}
+	return c.WithOptions(ExtraHeaders(map[string]string{idempotencyKeyHeader: key}))
+}
+
+// callSettings returns the retry config, HTTP client and extra headers for
+// a call, with c's request options applied.
+func (c *Client) callSettings() (RetryConfig, *http.Client, map[string]string) {
+	o := c.opts
+	if o == nil {
+		return c.cfg.retry, c.cfg.httpClient, nil
+	}
+	retry, hc := c.cfg.retry, c.cfg.httpClient
+	if o.retry != nil {
+		retry = *o.retry
+	}
+	if o.timeout > 0 {
+		copied := *hc
+		copied.Timeout = o.timeout
+		hc = &copied
+	}
+	return retry, hc, o.headers
+}
@@ -0,0 +1,83 @@
+package agentlens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequestOptions(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/api/health":
+			if r.Header.Get("X-Trace") != "abc" {
+				t.Errorf("expected extra header, got %q", r.Header.Get("X-Trace"))
+			}
+			time.Sleep(100 * time.Millisecond)
+			w.Write([]byte(`{"status":"ok"}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"error":"boom"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key",
+		WithTimeout(20*time.Millisecond),
+		WithRetry(RetryConfig{MaxRetries: 2, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond}),
+	)
+	ctx := context.Background()
+	traced := c.WithOptions(ExtraHeaders(map[string]string{"X-Trace": "abc"}))
+
+	// The client timeout is too short for the slow endpoint; a per-request
+	// timeout lifts it for calls through the derived client only.
+	if _, err := traced.WithOptions(PerRequestTimeout(time.Second)).Health(ctx); err != nil {
+		t.Fatalf("expected per-request timeout to apply: %v", err)
+	}
+	if _, err := traced.WithOptions(PerRequestRetry(RetryConfig{})).Health(ctx); err == nil {
+		t.Fatal("expected client timeout to apply without an override")
+	}
+
+	requests.Store(0)
+	if _, err := c.WithOptions(PerRequestRetry(RetryConfig{})).GetAgent(ctx, "a1"); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected no retries with PerRequestRetry, got %d requests", n)
+	}
+	requests.Store(0)
+	c.GetAgent(ctx, "a1")
+	if n := requests.Load(); n != 3 {
+		t.Errorf("expected client retry config without options, got %d requests", n)
+	}
+}
+
+func TestWithOptionsSharesState(t *testing.T) {
+	var headers []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("X-Trace"))
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithBatching(WithMaxBatchSize(10)))
+	traced := c.WithOptions(ExtraHeaders(map[string]string{"X-Trace": "abc"}))
+	// Events queued through either client share one sender, which sends
+	// with the parent client's settings.
+	traced.EnqueueEvent(Event{SessionID: "s1", EventType: "custom"})
+	c.EnqueueEvent(Event{SessionID: "s1", EventType: "custom"})
+	if err := traced.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Errorf("expected Close on the parent to be a no-op after the derived client closed: %v", err)
+	}
+	if len(headers) != 1 || headers[0] != "" {
+		t.Errorf("expected one batch without the derived client's header, got %q", headers)
+	}
+}
@@ -0,0 +1,76 @@
+package agentlens
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// requestIDHeader is the response header carrying the server's request ID.
+const requestIDHeader = "X-Request-ID"
+
+// defaultMaxResponseBytes caps response bodies unless WithMaxResponseBytes
+// says otherwise.
+const defaultMaxResponseBytes = 32 << 20
+
+// ErrResponseTooLarge is returned, wrapped, when a response body exceeds the
+// WithMaxResponseBytes limit.
+var ErrResponseTooLarge = errors.New("agentlens: response body too large")
+
+// readResponseBody reads resp.Body, decompressing it if the server gzipped
+// it, and fails with ErrResponseTooLarge once more than max decompressed
+// bytes arrive. max <= 0 means no limit.
+func readResponseBody(resp *http.Response, max int64) ([]byte, error) {
+	var r io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") && !resp.Uncompressed {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if max <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("%w: exceeds %d bytes (see WithMaxResponseBytes)", ErrResponseTooLarge, max)
+	}
+	return data, nil
+}
+
+// ResponseMetadata receives details of the last HTTP response for a call.
+// See WithResponseMetadata.
+type ResponseMetadata struct {
+	// RequestID is the server's X-Request-ID header, if any.
+	RequestID string
+	// StatusCode is the HTTP status of the last attempt.
+	StatusCode int
+}
+
+type responseMetadataKey struct{}
+
+// WithResponseMetadata returns a context that makes client methods record the
+// final response's metadata in md, for successful calls as well as errors:
+//
+//	var md agentlens.ResponseMetadata
+//	_, err := client.GetAgent(agentlens.WithResponseMetadata(ctx, &md), id)
+//	log.Printf("request %s", md.RequestID)
+//
+// md must not be shared between concurrent calls.
+func WithResponseMetadata(ctx context.Context, md *ResponseMetadata) context.Context {
+	return context.WithValue(ctx, responseMetadataKey{}, md)
+}
+
+func responseMetadataFrom(ctx context.Context) *ResponseMetadata {
+	md, _ := ctx.Value(responseMetadataKey{}).(*ResponseMetadata)
+	return md
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -15,6 +16,16 @@ type RetryConfig struct {
 	BackoffBase time.Duration
 	// BackoffMax is the maximum delay between retries (default 30s).
 	BackoffMax time.Duration
+	// BackoffResetAfter applies to long-running polling loops such as
+	// WatchHealth: their error backoff resets only after calls have
+	// succeeded for this long, so a flapping server keeps a long delay
+	// while one that stays up is polled normally again. The default (0)
+	// resets on the first success.
+	BackoffResetAfter time.Duration
+	// RandSource, if set, supplies the backoff jitter instead of the global
+	// math/rand source, so a seeded source (rand.NewSource(1)) gives the same
+	// delays on every run. It is used under a lock and may be shared.
+	RandSource rand.Source
 }
 
 func defaultRetryConfig() RetryConfig {
@@ -35,7 +46,8 @@ func shouldRetry(err error) bool {
 	if errors.As(err, &connErr) {
 		return true
 	}
-	// 429 and 503 are retryable
+	// 429, 502, 503 and 504 are retryable; 500 is not, as it usually
+	// indicates a server bug rather than a transient condition.
 	var rlErr *RateLimitError
 	if errors.As(err, &rlErr) {
 		return true
@@ -44,14 +56,83 @@ func shouldRetry(err error) bool {
 	if errors.As(err, &bpErr) {
 		return true
 	}
+	var gwErr *GatewayError
+	if errors.As(err, &gwErr) {
+		return true
+	}
 	return false
 }
 
+// retryable reports whether do should retry after err, which failed the
+// given 0-based attempt, consulting WithRetryPredicate if set.
+func (c *Client) retryable(err error, attempt int) bool {
+	if c.cfg.retryPredicate != nil {
+		return c.cfg.retryPredicate(err, attempt+1)
+	}
+	return shouldRetry(err)
+}
+
+// retryAfterOf returns the server-requested delay in seconds carried by err,
+// or nil if there is none.
+func retryAfterOf(err error) *float64 {
+	switch e := err.(type) {
+	case *RateLimitError:
+		return e.RetryAfter
+	case *BackpressureError:
+		return e.RetryAfter
+	}
+	return nil
+}
+
+// loopBackoff counts consecutive failures of a long-running loop, resetting
+// once the loop has been succeeding for resetAfter.
+type loopBackoff struct {
+	resetAfter time.Duration
+	clock      clock
+
+	failures int
+	upSince  time.Time // start of the current run of successes, or zero
+}
+
+// failed records a failure and returns the number of failures before it.
+func (b *loopBackoff) failed() int {
+	b.upSince = time.Time{}
+	n := b.failures
+	b.failures++
+	return n
+}
+
+// succeeded records a success, resetting the failure count once successes
+// have lasted resetAfter.
+func (b *loopBackoff) succeeded() {
+	now := b.clock.Now()
+	if b.upSince.IsZero() {
+		b.upSince = now
+	}
+	if now.Sub(b.upSince) >= b.resetAfter {
+		b.failures = 0
+	}
+}
+
+// randSourceMu guards every RetryConfig.RandSource, which need not be safe
+// for concurrent use.
+var randSourceMu sync.Mutex
+
+// jitter returns a float in [0, 1) from cfg.RandSource or the global source.
+func (cfg RetryConfig) jitter() float64 {
+	if cfg.RandSource == nil {
+		return rand.Float64()
+	}
+	randSourceMu.Lock()
+	defer randSourceMu.Unlock()
+	return rand.New(cfg.RandSource).Float64()
+}
+
 // backoffDelay calculates the delay for a given attempt:
 // min(base * 2^attempt + rand(0, base), max)
 func backoffDelay(cfg RetryConfig, attempt int) time.Duration {
 	base := float64(cfg.BackoffBase)
-	delay := base*math.Pow(2, float64(attempt)) + rand.Float64()*base
+	delay := base*math.Pow(2, float64(attempt)) + cfg.jitter()*base
 	max := float64(cfg.BackoffMax)
 	if delay > max {
 		delay = max
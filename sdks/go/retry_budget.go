@@ -0,0 +1,119 @@
+package agentlens
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// retryBudgetWindow is how many seconds of minPerSec reserve, and of
+// request deposits, the budget can accumulate.
+const retryBudgetWindow = 10
+
+// retryBudget is a client-wide token bucket limiting retries, in the style
+// of Finagle and Envoy retry budgets. Every call deposits ratio tokens, every
+// retry withdraws one, and minPerSec tokens per second are added so that
+// low-traffic clients can still retry.
+type retryBudget struct {
+	ratio     float64
+	minPerSec float64
+	capacity  float64
+	clock     clock
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	retries atomic.Int64
+	denied  atomic.Int64
+}
+
+func newRetryBudget(ratio float64, minPerSec int, clk clock) *retryBudget {
+	capacity := retryBudgetWindow * float64(minPerSec)
+	if capacity < retryBudgetWindow {
+		capacity = retryBudgetWindow
+	}
+	return &retryBudget{
+		ratio:     ratio,
+		minPerSec: float64(minPerSec),
+		capacity:  capacity,
+		clock:     clk,
+		tokens:    float64(minPerSec),
+		last:      clk.Now(),
+	}
+}
+
+// deposit credits the budget for a new call.
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	b.tokens = min(b.capacity, b.tokens+b.ratio)
+	b.mu.Unlock()
+}
+
+// withdraw takes a token for one retry, reporting false if none is left.
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked()
+	if b.tokens < 1 {
+		b.denied.Add(1)
+		return false
+	}
+	b.tokens--
+	b.retries.Add(1)
+	return true
+}
+
+// available returns the current token balance.
+func (b *retryBudget) available() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked()
+	return b.tokens
+}
+
+func (b *retryBudget) refillLocked() {
+	now := b.clock.Now()
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.minPerSec)
+	b.last = now
+}
+
+// ClientStats is a snapshot of client-wide counters.
+type ClientStats struct {
+	// RetryBudgetEnabled reports whether WithRetryBudget is set. The retry
+	// fields are zero when it is not.
+	RetryBudgetEnabled bool
+	// RetryBudgetAvailable is the number of retries the budget currently allows.
+	RetryBudgetAvailable float64
+	// RetriesAttempted is the number of retries the budget has allowed.
+	RetriesAttempted int64
+	// RetriesDenied is the number of retries skipped because the budget was exhausted.
+	RetriesDenied int64
+	// EventsSampledOut is the number of events dropped by sampling.
+	EventsSampledOut int64
+	// RequestsRateLimited is the number of request attempts WithRateLimit
+	// delayed, and RateLimitWait their total delay.
+	RequestsRateLimited int64
+	RateLimitWait       time.Duration
+	// HedgedRequests is the number of extra requests sent by WithHedging.
+	HedgedRequests int64
+	// RequestsInFlight is the number of requests currently being sent or
+	// awaiting a response; see WithMaxConcurrentRequests.
+	RequestsInFlight int64
+}
+
+// Stats returns a snapshot of the client's counters.
+func (c *Client) Stats() ClientStats {
+	st := ClientStats{EventsSampledOut: c.sampledOut.Load(), HedgedRequests: c.hedged.Load(), RequestsInFlight: c.inFlight.count.Load()}
+	if c.rateLimiter != nil {
+		st.RequestsRateLimited = c.rateLimiter.delayed.Load()
+		st.RateLimitWait = time.Duration(c.rateLimiter.waitNanos.Load())
+	}
+	if c.retryBudget != nil {
+		st.RetryBudgetEnabled = true
+		st.RetryBudgetAvailable = c.retryBudget.available()
+		st.RetriesAttempted = c.retryBudget.retries.Load()
+		st.RetriesDenied = c.retryBudget.denied.Load()
+	}
+	return st
+}
@@ -0,0 +1,67 @@
+package agentlens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBudgetCapsRetries(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"overloaded"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key",
+		WithRetry(RetryConfig{MaxRetries: 3, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond}),
+		WithRetryBudget(0.1, 0),
+	)
+	const calls = 50
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Health(context.Background()); err == nil {
+				t.Error("expected error")
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Without a budget, 50 calls would make 200 requests; with a 10% budget
+	// at most 5 retries are allowed.
+	if n := requests.Load(); n > calls+5 {
+		t.Errorf("expected at most %d requests, got %d", calls+5, n)
+	}
+	st := c.Stats()
+	if !st.RetryBudgetEnabled || st.RetriesAttempted > 5 || st.RetriesDenied == 0 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+	if st.RetriesAttempted+calls != requests.Load() {
+		t.Errorf("expected %d requests, got %d", st.RetriesAttempted+calls, requests.Load())
+	}
+}
+
+func TestRetryBudgetRefills(t *testing.T) {
+	clk := newFakeClock(time.Unix(0, 0))
+	b := newRetryBudget(0, 2, clk)
+	if !b.withdraw() || !b.withdraw() || b.withdraw() {
+		t.Fatal("expected an initial reserve of exactly minPerSec retries")
+	}
+	clk.Advance(time.Second)
+	if got := b.available(); got != 2 {
+		t.Errorf("expected 2 tokens after 1s, got %v", got)
+	}
+	clk.Advance(time.Hour)
+	if got := b.available(); got != 20 {
+		t.Errorf("expected balance capped at 10s of reserve, got %v", got)
+	}
+}
@@ -2,6 +2,7 @@ package agentlens
 
 import (
 	"context"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
@@ -73,6 +74,40 @@ func TestRetry503ThenSuccess(t *testing.T) {
 	}
 }
 
+func TestRetry503HonoursRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var first, second time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "0.2")
+			w.WriteHeader(503)
+			w.Write([]byte(`{"error":"backpressure"}`))
+			return
+		}
+		second = time.Now()
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithRetry(RetryConfig{
+		MaxRetries:  2,
+		BackoffBase: time.Millisecond,
+		BackoffMax:  10 * time.Millisecond,
+	}))
+
+	var result HealthResult
+	if err := c.do(context.Background(), "GET", "/api/health", nil, &result, true); err != nil {
+		t.Fatalf("expected success, got: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls.Load())
+	}
+	if d := second.Sub(first); d < 200*time.Millisecond {
+		t.Errorf("retried after %v, want at least the 200ms Retry-After", d)
+	}
+}
+
 func TestNoRetryOn401(t *testing.T) {
 	var calls atomic.Int32
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -137,8 +172,134 @@ func TestShouldRetry(t *testing.T) {
 	if !shouldRetry(mapHTTPError(503, "bp", nil, nil)) {
 		t.Error("503 should be retryable")
 	}
+	if !shouldRetry(mapHTTPError(502, "gw", nil, nil)) {
+		t.Error("502 should be retryable")
+	}
+	if !shouldRetry(mapHTTPError(504, "gw", nil, nil)) {
+		t.Error("504 should be retryable")
+	}
+	if shouldRetry(mapHTTPError(500, "ise", nil, nil)) {
+		t.Error("500 should not be retryable")
+	}
 	connErr := &ConnectionError{APIError: newAPIError("timeout", 0, "CONNECTION_ERROR", nil)}
 	if !shouldRetry(connErr) {
 		t.Error("ConnectionError should be retryable")
 	}
 }
+
+func TestWithRetryPredicate(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error":"lock conflict"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	var seen []int
+	c := NewClient(srv.URL, "key",
+		WithRetry(RetryConfig{MaxRetries: 3, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond}),
+		WithRetryPredicate(func(err error, attempt int) bool {
+			seen = append(seen, attempt)
+			return StatusCode(err) == http.StatusConflict || IsRetryable(err)
+		}))
+	if _, err := c.GetAgent(context.Background(), "a1"); err != nil {
+		t.Fatalf("expected the 409s to be retried, got %v", err)
+	}
+	if attempts != 3 || len(seen) != 2 || seen[0] != 1 || seen[1] != 2 {
+		t.Errorf("expected 3 attempts and predicate calls for attempts 1 and 2, got %d and %v", attempts, seen)
+	}
+
+	// Without the predicate, 409 is not retried.
+	attempts = 0
+	c = NewClient(srv.URL, "key", WithRetry(RetryConfig{MaxRetries: 3, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond}))
+	if _, err := c.GetAgent(context.Background(), "a1"); StatusCode(err) != http.StatusConflict || attempts != 1 {
+		t.Errorf("expected a single 409 attempt, got %v after %d", err, attempts)
+	}
+}
+
+func TestLoopBackoffResetAfter(t *testing.T) {
+	clk := newFakeClock(time.Unix(0, 0))
+	b := loopBackoff{resetAfter: 5 * time.Second, clock: clk}
+
+	// Down: failures accumulate.
+	b.failed()
+	b.failed()
+	// Briefly up, then down again: the backoff is kept.
+	b.succeeded()
+	clk.Advance(time.Second)
+	if n := b.failed(); n != 2 {
+		t.Errorf("expected backoff to survive a short recovery, got %d prior failures", n)
+	}
+	// Up for longer than resetAfter: the backoff resets.
+	b.succeeded()
+	clk.Advance(6 * time.Second)
+	b.succeeded()
+	if n := b.failed(); n != 0 {
+		t.Errorf("expected backoff to reset after a stable period, got %d prior failures", n)
+	}
+
+	// The default resets on the first success.
+	b = loopBackoff{clock: clk}
+	b.failed()
+	b.succeeded()
+	if n := b.failed(); n != 0 {
+		t.Errorf("expected immediate reset by default, got %d", n)
+	}
+}
+
+func TestBackoffDelaySeededIsDeterministic(t *testing.T) {
+	delays := func() []time.Duration {
+		cfg := RetryConfig{
+			BackoffBase: 100 * time.Millisecond,
+			BackoffMax:  time.Minute,
+			RandSource:  rand.NewSource(42),
+		}
+		var out []time.Duration
+		for attempt := 0; attempt < 4; attempt++ {
+			out = append(out, backoffDelay(cfg, attempt))
+		}
+		return out
+	}
+	a, b := delays(), delays()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("attempt %d: %v != %v with the same seed", i, a[i], b[i])
+		}
+		lo := 100 * time.Millisecond << i
+		if a[i] < lo || a[i] >= lo+100*time.Millisecond {
+			t.Errorf("attempt %d: delay %v outside [%v, %v)", i, a[i], lo, lo+100*time.Millisecond)
+		}
+	}
+}
+
+func TestLogLlmCallRetryReusesIdempotencyKey(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			w.WriteHeader(503)
+			w.Write([]byte(`{"error":"backpressure"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithRetry(RetryConfig{
+		MaxRetries:  2,
+		BackoffBase: time.Millisecond,
+		BackoffMax:  10 * time.Millisecond,
+	}))
+	callID, err := c.LogLlmCall(context.Background(), "s1", "a1", &LogLlmCallParams{Provider: "openai", Model: "gpt-4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != callID || keys[1] != callID {
+		t.Errorf("expected Idempotency-Key %q on both attempts, got %q", callID, keys)
+	}
+}
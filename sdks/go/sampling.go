@@ -0,0 +1,166 @@
+package agentlens
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// healthSampleTTL is how long WithHealthSampling reuses an agent's health score.
+const healthSampleTTL = time.Minute
+
+// WithAdaptiveSampling sends each event with probability fn(agentID), so
+// sampled-out events never reach the network. Events with severity error or
+// critical are always kept. A rate >= 1 keeps everything and a rate <= 0
+// drops everything else. LogLlmCall, LogToolCall and StartLlmCall sample a
+// call as a whole so paired events stay together. fn must be fast and safe
+// for concurrent use; see WithHealthSampling for a ready-made policy.
+func WithAdaptiveSampling(fn func(agentID string) float64) ClientOption {
+	return func(c *clientConfig) {
+		c.sampler = fn
+		c.healthSampling = nil
+	}
+}
+
+// WithHealthSampling is WithAdaptiveSampling with a health-based policy:
+// events of an agent whose health score (0-100, from GetHealth, cached for a
+// minute and refreshed in the background) is at least minScore are sampled
+// at healthyRate, e.g. 0.1. Agents below minScore, or whose score is not
+// known yet, are sampled at 100% so incidents keep full detail.
+func WithHealthSampling(healthyRate, minScore float64) ClientOption {
+	return func(c *clientConfig) {
+		c.sampler = nil
+		c.healthSampling = &healthSamplingConfig{healthyRate: healthyRate, minScore: minScore}
+	}
+}
+
+// WithSessionSampling keeps or drops whole sessions: an event is sent only
+// if a hash of its SessionID falls below rate (0-1), so every event of a
+// sampled-in session is kept, regardless of severity, and every event of a
+// sampled-out session is dropped. The decision depends only on the session
+// ID, so it is consistent across processes and clients using the same rate.
+// It applies before WithAdaptiveSampling.
+func WithSessionSampling(rate float64) ClientOption {
+	return func(c *clientConfig) {
+		c.sessionSampling = true
+		c.sessionRate = rate
+	}
+}
+
+// sessionSampled reports whether sessionID falls within rate, using the
+// 64-bit FNV-1a hash of the ID. FNV barely mixes trailing bytes into the
+// high bits, so sequential IDs like "sess-1", "sess-2" would land together;
+// the MurmurHash3 finalizer spreads them evenly.
+func sessionSampled(sessionID string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(sessionID))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return float64(x) < rate*math.MaxUint64
+}
+
+type healthSamplingConfig struct {
+	healthyRate float64
+	minScore    float64
+}
+
+// healthSampler caches agent health scores for WithHealthSampling.
+type healthSampler struct {
+	c   *Client
+	cfg healthSamplingConfig
+
+	mu     sync.Mutex
+	scores map[string]healthSample
+}
+
+type healthSample struct {
+	score      float64
+	known      bool
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+func newHealthSampler(c *Client, cfg healthSamplingConfig) *healthSampler {
+	return &healthSampler{c: c, cfg: cfg, scores: map[string]healthSample{}}
+}
+
+// rate returns the sample rate for agentID, starting a background refresh
+// of its score when it is missing or stale and the client is not closed.
+func (s *healthSampler) rate(agentID string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.scores[agentID]
+	if (!ok || s.c.cfg.clock.Now().Sub(h.fetchedAt) >= healthSampleTTL) && !h.refreshing && !s.closed() {
+		h.refreshing = true
+		s.scores[agentID] = h
+		go s.refresh(agentID)
+	}
+	if !h.known || h.score < s.cfg.minScore {
+		return 1
+	}
+	return s.cfg.healthyRate
+}
+
+// closed reports whether the client has been closed.
+func (s *healthSampler) closed() bool {
+	select {
+	case <-s.c.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// refresh fetches agentID's health score. Close cancels a refresh in
+// progress.
+func (s *healthSampler) refresh(agentID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.c.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	var result HealthScore
+	err := s.c.do(ctx, http.MethodGet, "/api/agents/"+url.PathEscape(agentID)+"/health", nil, &result, false)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Without a score (e.g. 404 for an agent with no sessions yet), keep
+	// sampling at 100% and try again after the TTL.
+	s.scores[agentID] = healthSample{score: result.OverallScore, known: err == nil, fetchedAt: s.c.cfg.clock.Now()}
+}
+
+// keepEvent reports whether an event with the given session, agent and
+// severity survives session sampling and then adaptive sampling.
+func (c *Client) keepEvent(sessionID, agentID, severity string) bool {
+	if c.cfg.sessionSampling && !sessionSampled(sessionID, c.cfg.sessionRate) {
+		c.sampledOut.Add(1)
+		return false
+	}
+	if c.sampleRate == nil || isErrorSeverity(severity) {
+		return true
+	}
+	rate := c.sampleRate(agentID)
+	keep := rate >= 1 || rate > 0 && rand.Float64() < rate
+	if !keep {
+		c.sampledOut.Add(1)
+	}
+	return keep
+}
@@ -0,0 +1,181 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveSampling(t *testing.T) {
+	var mu sync.Mutex
+	var received []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		received = append(received, body.Events...)
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	rates := map[string]float64{"quiet": 0, "loud": 1}
+	c := NewClient(srv.URL, "key", WithAdaptiveSampling(func(agentID string) float64 { return rates[agentID] }))
+	ctx := context.Background()
+	c.SendEvents(ctx, []Event{
+		{SessionID: "s1", AgentID: "quiet", EventType: "custom", Severity: "info"},
+		{SessionID: "s1", AgentID: "quiet", EventType: "custom", Severity: "error"},
+		{SessionID: "s1", AgentID: "loud", EventType: "custom", Severity: "info"},
+	})
+	c.LogLlmCall(ctx, "s1", "quiet", &LogLlmCallParams{Provider: "openai", Model: "gpt-4"})
+	c.LogToolCall(ctx, "s1", "quiet", &LogToolCallParams{ToolName: "search", Error: "timeout"})
+
+	mu.Lock()
+	defer mu.Unlock()
+	var got []string
+	for _, e := range received {
+		got = append(got, e.AgentID+"/"+e.EventType+"/"+e.Severity)
+	}
+	want := "quiet/custom/error loud/custom/info quiet/tool_call/info quiet/tool_error/error"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+	if n := c.Stats().EventsSampledOut; n != 2 {
+		t.Errorf("expected 2 sampled-out events (one LLM call counts once), got %d", n)
+	}
+}
+
+func TestHealthSampling(t *testing.T) {
+	var mu sync.Mutex
+	var healthCalls, events int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/api/agents/healthy/health":
+			healthCalls++
+			w.Write([]byte(`{"agentId":"healthy","overallScore":95}`))
+		case "/api/agents/sick/health":
+			healthCalls++
+			w.Write([]byte(`{"agentId":"sick","overallScore":40}`))
+		default:
+			var body struct {
+				Events []Event `json:"events"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			events += len(body.Events)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+
+	clk := newFakeClock(time.Unix(0, 0))
+	c := NewClient(srv.URL, "key", WithHealthSampling(0, 80), withClock(clk))
+	ev := func(agent string) []Event {
+		return []Event{{SessionID: "s1", AgentID: agent, EventType: "custom", Severity: "info"}}
+	}
+
+	// Unknown health: everything is kept while the score is fetched.
+	c.SendEvents(context.Background(), ev("healthy"))
+	c.SendEvents(context.Background(), ev("sick"))
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if c.sampleRate("healthy") == 0 && c.sampleRate("sick") == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.SendEvents(context.Background(), ev("healthy"))
+	c.SendEvents(context.Background(), ev("sick"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if events != 3 {
+		t.Errorf("expected the healthy agent's second event to be sampled out, got %d events", events)
+	}
+	if healthCalls != 2 {
+		t.Errorf("expected one cached health lookup per agent, got %d", healthCalls)
+	}
+}
+
+func TestHealthSamplingStopsOnClose(t *testing.T) {
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/health") {
+			close(started)
+			<-r.Context().Done()
+			close(canceled)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithHealthSampling(0, 80))
+	c.sampleRate("a1")
+	<-started
+	c.Close(context.Background())
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Close to cancel the health refresh")
+	}
+	c.sampleRate("a2") // must not start a refresh after Close
+}
+
+func TestSessionSampling(t *testing.T) {
+	var mu sync.Mutex
+	sessions := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		for _, e := range body.Events {
+			sessions[e.SessionID]++
+		}
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithSessionSampling(0.5))
+	const numSessions, perSession = 200, 10
+	for i := 0; i < perSession; i++ {
+		var batch []Event
+		for s := 0; s < numSessions; s++ {
+			sev := "info"
+			if i == 0 {
+				sev = "error"
+			}
+			batch = append(batch, Event{SessionID: fmt.Sprintf("sess-%d", s), AgentID: "a1", EventType: "custom", Severity: sev})
+		}
+		if err := c.SendEvents(context.Background(), batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for id, n := range sessions {
+		if n != perSession {
+			t.Errorf("session %s: expected all %d events or none, got %d", id, perSession, n)
+		}
+		if !sessionSampled(id, 0.5) {
+			t.Errorf("session %s was sent but is not sampled in", id)
+		}
+	}
+	if kept := len(sessions); kept < numSessions/4 || kept > numSessions*3/4 {
+		t.Errorf("expected about half of %d sessions to be kept, got %d", numSessions, kept)
+	}
+}
@@ -0,0 +1,114 @@
+package agentlens
+
+import "context"
+
+// ScopedClient is a Client bound to one session and agent, so logging calls
+// don't need those IDs at every call site. It shares the parent's transport,
+// retry, fail-open and other configuration; all other Client methods are
+// available unchanged through the embedded *Client.
+type ScopedClient struct {
+	*Client
+	sessionID string
+	agentID   string
+}
+
+// Scoped returns a ScopedClient that logs to sessionID and agentID. It is
+// cheap to create, e.g. once per agent run.
+func (c *Client) Scoped(sessionID, agentID string) *ScopedClient {
+	return &ScopedClient{Client: c, sessionID: sessionID, agentID: agentID}
+}
+
+// SessionID returns the session the client is bound to.
+func (s *ScopedClient) SessionID() string { return s.sessionID }
+
+// AgentID returns the agent the client is bound to.
+func (s *ScopedClient) AgentID() string { return s.agentID }
+
+// LogLlmCall is Client.LogLlmCall for the bound session and agent.
+func (s *ScopedClient) LogLlmCall(ctx context.Context, params *LogLlmCallParams) (string, error) {
+	return s.Client.LogLlmCall(ctx, s.sessionID, s.agentID, params)
+}
+
+// StartLlmCall is Client.StartLlmCall for the bound session and agent.
+func (s *ScopedClient) StartLlmCall(ctx context.Context, params *LogLlmCallParams) (*LlmCallHandle, error) {
+	return s.Client.StartLlmCall(ctx, s.sessionID, s.agentID, params)
+}
+
+// LogToolCall is Client.LogToolCall for the bound session and agent.
+func (s *ScopedClient) LogToolCall(ctx context.Context, params *LogToolCallParams) (string, error) {
+	return s.Client.LogToolCall(ctx, s.sessionID, s.agentID, params)
+}
+
+// LogEvent is Client.LogEvent for the bound session and agent.
+func (s *ScopedClient) LogEvent(ctx context.Context, eventType, severity string, payload map[string]any) (string, error) {
+	return s.Client.LogEvent(ctx, s.sessionID, s.agentID, eventType, severity, payload)
+}
+
+// LogError is Client.LogError for the bound session and agent.
+func (s *ScopedClient) LogError(ctx context.Context, err error) (string, error) {
+	return s.Client.LogError(ctx, s.sessionID, s.agentID, err)
+}
+
+// SendEvents is Client.SendEvents with empty SessionID and AgentID fields
+// filled from the scope. The caller's slice is not modified.
+func (s *ScopedClient) SendEvents(ctx context.Context, events []Event) error {
+	return s.Client.SendEvents(ctx, s.fillAll(events))
+}
+
+// SendEventsWithResult is Client.SendEventsWithResult with empty SessionID
+// and AgentID fields filled from the scope. The caller's slice is not
+// modified.
+func (s *ScopedClient) SendEventsWithResult(ctx context.Context, events []Event) (*BatchSendResult, error) {
+	return s.Client.SendEventsWithResult(ctx, s.fillAll(events))
+}
+
+// EnqueueEvent is Client.EnqueueEvent with empty SessionID and AgentID
+// fields filled from the scope.
+func (s *ScopedClient) EnqueueEvent(e Event) {
+	s.Client.EnqueueEvent(s.fill(e))
+}
+
+func (s *ScopedClient) fillAll(events []Event) []Event {
+	scoped := make([]Event, len(events))
+	for i, e := range events {
+		scoped[i] = s.fill(e)
+	}
+	return scoped
+}
+
+func (s *ScopedClient) fill(e Event) Event {
+	if e.SessionID == "" {
+		e.SessionID = s.sessionID
+	}
+	if e.AgentID == "" {
+		e.AgentID = s.agentID
+	}
+	return e
+}
+
+type scopeKey struct{}
+
+type scopeValue struct{ sessionID, agentID string }
+
+// ContextWithScope returns a context carrying sessionID and agentID for the
+// *Ctx logging methods, e.g. set once by HTTP middleware.
+func ContextWithScope(ctx context.Context, sessionID, agentID string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scopeValue{sessionID, agentID})
+}
+
+// ScopeFromContext returns the session and agent IDs set by
+// ContextWithScope. ok is false if ctx carries none.
+func ScopeFromContext(ctx context.Context) (sessionID, agentID string, ok bool) {
+	s, ok := ctx.Value(scopeKey{}).(scopeValue)
+	return s.sessionID, s.agentID, ok
+}
+
+// LogLlmCallCtx is LogLlmCall with the session and agent taken from ctx
+// (see ContextWithScope). It returns a *ValidationError if ctx has no scope.
+func (c *Client) LogLlmCallCtx(ctx context.Context, params *LogLlmCallParams) (string, error) {
+	sessionID, agentID, ok := ScopeFromContext(ctx)
+	if !ok || sessionID == "" {
+		return "", newFieldValidationError("sessionId", "not set in context; use ContextWithScope")
+	}
+	return c.LogLlmCall(ctx, sessionID, agentID, params)
+}
@@ -0,0 +1,122 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestScopedClient(t *testing.T) {
+	var mu sync.Mutex
+	var received []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []map[string]any `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		received = append(received, body.Events...)
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	sc := NewClient(srv.URL, "key").Scoped("s1", "a1")
+	if _, err := sc.LogLlmCall(context.Background(), &LogLlmCallParams{Provider: "openai", Model: "gpt-4"}); err != nil {
+		t.Fatal(err)
+	}
+	events := []Event{{ID: "e1", EventType: "custom"}, {ID: "e2", EventType: "custom", AgentID: "other"}}
+	if err := sc.SendEvents(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+	if events[0].SessionID != "" {
+		t.Error("SendEvents must not modify the caller's events")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(received))
+	}
+	for _, e := range received[:3] {
+		if e["sessionId"] != "s1" || e["agentId"] != "a1" {
+			t.Errorf("expected scoped IDs, got %v/%v", e["sessionId"], e["agentId"])
+		}
+	}
+	if received[3]["agentId"] != "other" {
+		t.Errorf("explicit agentId should be kept, got %v", received[3]["agentId"])
+	}
+}
+
+func TestScopedClientBatchPaths(t *testing.T) {
+	var mu sync.Mutex
+	var received []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []map[string]any `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		received = append(received, body.Events...)
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithBatching(WithMaxBatchSize(10)))
+	sc := c.Scoped("s1", "a1")
+	events := []Event{{ID: "e1", EventType: "custom"}}
+	if _, err := sc.SendEventsWithResult(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+	if events[0].SessionID != "" {
+		t.Error("SendEventsWithResult must not modify the caller's events")
+	}
+	sc.EnqueueEvent(Event{ID: "e2", EventType: "custom"})
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(received))
+	}
+	for _, e := range received {
+		if e["sessionId"] != "s1" || e["agentId"] != "a1" {
+			t.Errorf("expected scoped IDs, got %v/%v", e["sessionId"], e["agentId"])
+		}
+	}
+}
+
+func TestLogLlmCallCtx(t *testing.T) {
+	var received []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []map[string]any `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body.Events...)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	ctx := ContextWithScope(context.Background(), "s1", "a1")
+	if _, err := c.LogLlmCallCtx(ctx, &LogLlmCallParams{Provider: "openai", Model: "gpt-4"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 2 || received[0]["sessionId"] != "s1" || received[0]["agentId"] != "a1" {
+		t.Errorf("expected events scoped from context, got %v", received)
+	}
+
+	_, err := c.LogLlmCallCtx(context.Background(), &LogLlmCallParams{Provider: "openai", Model: "gpt-4"})
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Errorf("expected ValidationError without scope, got %v", err)
+	}
+}
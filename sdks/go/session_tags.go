@@ -0,0 +1,43 @@
+package agentlens
+
+import "context"
+
+// SessionQuery.TagMatch values.
+const (
+	// TagMatchAny matches sessions with at least one of the tags.
+	TagMatchAny = "any"
+	// TagMatchAll matches sessions with every one of the tags.
+	TagMatchAll = "all"
+)
+
+// GetSessionsByTags returns sessions tagged with tags, combined according
+// to match (TagMatchAny or TagMatchAll; empty means TagMatchAny), using the
+// server's default page size. Use GetSessions with SessionQuery.Tags to add
+// other filters or paginate.
+func (c *Client) GetSessionsByTags(ctx context.Context, tags []string, match string) (*SessionQueryResult, error) {
+	return c.GetSessions(ctx, &SessionQuery{Tags: tags, TagMatch: match})
+}
+
+// sessionsWithAllTags filters sessions to those carrying every tag. The
+// server only supports any-tag matching, so TagMatchAll is applied to each
+// page client-side.
+func sessionsWithAllTags(sessions []Session, tags []string) []Session {
+	kept := sessions[:0]
+	for _, s := range sessions {
+		have := make(map[string]bool, len(s.Tags))
+		for _, t := range s.Tags {
+			have[t] = true
+		}
+		all := true
+		for _, t := range tags {
+			if !have[t] {
+				all = false
+				break
+			}
+		}
+		if all {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
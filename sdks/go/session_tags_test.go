@@ -0,0 +1,38 @@
+package agentlens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetSessionsByTags(t *testing.T) {
+	var gotTags string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTags = r.URL.Query().Get("tags")
+		w.Write([]byte(`{"sessions":[
+			{"id":"s1","tags":["prod","billing"]},
+			{"id":"s2","tags":["prod"]},
+			{"id":"s3","tags":["billing","prod","eu"]}],"total":3,"hasMore":false}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+	ctx := context.Background()
+
+	res, err := c.GetSessionsByTags(ctx, []string{"prod", "billing"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotTags != "prod,billing" || len(res.Sessions) != 3 {
+		t.Errorf("any: sent tags=%q, got %d sessions", gotTags, len(res.Sessions))
+	}
+
+	res, err = c.GetSessionsByTags(ctx, []string{"prod", "billing"}, TagMatchAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Sessions) != 2 || res.Sessions[0].ID != "s1" || res.Sessions[1].ID != "s3" {
+		t.Errorf("all: expected s1 and s3, got %+v", res.Sessions)
+	}
+}
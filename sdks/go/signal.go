@@ -0,0 +1,55 @@
+package agentlens
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// signalFlushTimeout bounds the flush triggered by InstallSignalHandler.
+const signalFlushTimeout = 5 * time.Second
+
+// InstallSignalHandler shuts b down, flushing queued events within 5
+// seconds, when the process receives one of sigs (default SIGINT and
+// SIGTERM). It is opt-in so the SDK never captures signals on its own.
+//
+// The handler only flushes: the program should still handle the signal
+// itself (for example with signal.NotifyContext) to exit. After the first
+// signal the handler uninstalls itself, so if nothing else handles the
+// signal a second one terminates the process as usual. Call the returned
+// cancel function to uninstall the handler without shutting down; it waits
+// for a flush already in progress.
+//
+// Events enqueued after the signal, for example by code still logging while
+// the program winds down, are discarded with ErrBatchSenderClosed and
+// reported to the error callback.
+func (b *BatchSender) InstallSignalHandler(sigs ...os.Signal) (cancel func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer signal.Stop(ch)
+		select {
+		case <-ch:
+			ctx, cancel := context.WithTimeout(context.Background(), signalFlushTimeout)
+			defer cancel()
+			if err := b.Shutdown(ctx); err != nil && b.cfg.onError != nil {
+				b.cfg.onError(err)
+			}
+		case <-stop:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stop) })
+		<-done
+	}
+}
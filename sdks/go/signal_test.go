@@ -0,0 +1,84 @@
+package agentlens
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInstallSignalHandler(t *testing.T) {
+	var mu sync.Mutex
+	var sent []Event
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		mu.Lock()
+		sent = append(sent, events...)
+		mu.Unlock()
+		return nil
+	}, WithFlushInterval(time.Hour))
+	cancel := bs.InstallSignalHandler(os.Interrupt)
+	bs.Enqueue(Event{SessionID: "s1", EventType: "custom"})
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot signal self: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for bs.Stats().TotalSent == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	mu.Lock()
+	n := len(sent)
+	mu.Unlock()
+	if n != 1 {
+		t.Fatalf("expected queued event to be flushed on signal, got %d", n)
+	}
+	if err := bs.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown after signal: %v", err)
+	}
+}
+
+func TestInstallSignalHandlerEnqueueAfterSignal(t *testing.T) {
+	var mu sync.Mutex
+	var reported []error
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		return nil
+	}, WithFlushInterval(time.Hour), WithConcurrency(2),
+		WithBatchOnError(func(err error) {
+			mu.Lock()
+			reported = append(reported, err)
+			mu.Unlock()
+		}))
+	cancel := bs.InstallSignalHandler(os.Interrupt)
+	bs.Enqueue(Event{SessionID: "s1", EventType: "custom"})
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot signal self: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for bs.Stats().TotalSent == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel() // waits for the signal-triggered Shutdown to finish
+
+	bs.Enqueue(Event{SessionID: "s1", EventType: "custom"})
+	bs.Enqueue(Event{SessionID: "s1", EventType: "custom"})
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 2 || !errors.Is(reported[0], ErrBatchSenderClosed) {
+		t.Fatalf("expected events logged after the signal to be reported as dropped, got %v", reported)
+	}
+	if st := bs.Stats(); st.TotalSent != 1 || st.TotalDropped != 2 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
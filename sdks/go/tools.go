@@ -0,0 +1,94 @@
+package agentlens
+
+import (
+	"context"
+	"time"
+)
+
+// LogToolCallParams contains parameters for logging a tool call.
+type LogToolCallParams struct {
+	ToolName string `json:"toolName"`
+	// ServerName is the MCP server that provides the tool, if any.
+	ServerName *string        `json:"serverName,omitempty"`
+	Arguments  map[string]any `json:"arguments"`
+	// Result is the tool output. Ignored when Error is set.
+	Result any `json:"result,omitempty"`
+	// Error is the failure message. When set, a tool_error event is sent
+	// instead of tool_response.
+	Error     string  `json:"error,omitempty"`
+	ErrorCode *string `json:"errorCode,omitempty"`
+	// DurationMs is the tool's latency in milliseconds.
+	DurationMs float64 `json:"durationMs"`
+	// Redact replaces argument values and the result with a placeholder,
+	// like LogLlmCallParams.Redact. Argument names and the error are kept.
+	Redact bool `json:"redact,omitempty"`
+}
+
+// LogToolCall logs a completed tool call by sending a tool_call event paired
+// with a tool_response (or tool_error, if params.Error is set) event sharing
+// a generated call ID, which is returned. With WithBatching, the events are
+// queued instead.
+func (c *Client) LogToolCall(ctx context.Context, sessionID, agentID string, params *LogToolCallParams) (string, error) {
+	callID := generateID()
+	severity := SeverityInfo
+	if params.Error != "" {
+		severity = SeverityError
+	}
+	if !c.keepEvent(sessionID, agentID, severity) {
+		return callID, nil
+	}
+	timestamp := c.cfg.clock.Now().UTC().Format(time.RFC3339Nano)
+
+	args := params.Arguments
+	var result any = params.Result
+	if params.Redact {
+		redacted := make(map[string]any, len(args))
+		for k := range args {
+			redacted[k] = redactedPlaceholder
+		}
+		args = redacted
+		if result != nil {
+			result = redactedPlaceholder
+		}
+	}
+	if args == nil {
+		args = map[string]any{}
+	}
+
+	callPayload := map[string]any{
+		"callId":    callID,
+		"toolName":  params.ToolName,
+		"arguments": args,
+	}
+	if params.ServerName != nil {
+		callPayload["serverName"] = *params.ServerName
+	}
+
+	resultType := EventTypeToolResponse
+	resultPayload := map[string]any{
+		"callId":     callID,
+		"toolName":   params.ToolName,
+		"durationMs": params.DurationMs,
+	}
+	if params.Error != "" {
+		resultType = EventTypeToolError
+		resultPayload["error"] = params.Error
+		if params.ErrorCode != nil {
+			resultPayload["errorCode"] = *params.ErrorCode
+		}
+	} else {
+		resultPayload["result"] = result
+	}
+	if params.Redact {
+		callPayload["redacted"] = true
+		resultPayload["redacted"] = true
+	}
+
+	callEvent := sdkEvent(sessionID, agentID, EventTypeToolCall, SeverityInfo, callPayload, timestamp)
+	resultEvent := sdkEvent(sessionID, agentID, resultType, severity, resultPayload, timestamp)
+	if c.enqueueBatched(callEvent, resultEvent) {
+		return callID, nil
+	}
+	err := c.postEvents(ctx, sessionID, agentID, []Event{callEvent, resultEvent}, nil)
+	return callID, err
+}
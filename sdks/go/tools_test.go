@@ -0,0 +1,63 @@
+package agentlens
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLogToolCall(t *testing.T) {
+	var events []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []map[string]any `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		events = body.Events
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "key")
+
+	callID, err := c.LogToolCall(context.Background(), "s1", "a1", &LogToolCallParams{
+		ToolName:   "search",
+		Arguments:  map[string]any{"q": "weather"},
+		Result:     map[string]any{"hits": 3},
+		DurationMs: 120,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0]["eventType"] != "tool_call" || events[1]["eventType"] != "tool_response" {
+		t.Fatalf("expected tool_call/tool_response pair, got %+v", events)
+	}
+	call, resp := events[0]["payload"].(map[string]any), events[1]["payload"].(map[string]any)
+	if call["callId"] != callID || resp["callId"] != callID {
+		t.Errorf("expected shared callId %s", callID)
+	}
+	if call["arguments"].(map[string]any)["q"] != "weather" || resp["durationMs"] != 120.0 || resp["result"] == nil {
+		t.Errorf("unexpected payloads: %v / %v", call, resp)
+	}
+
+	_, err = c.LogToolCall(context.Background(), "s1", "a1", &LogToolCallParams{
+		ToolName:  "search",
+		Arguments: map[string]any{"q": "secret"},
+		Error:     "upstream timeout",
+		Redact:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events[1]["eventType"] != "tool_error" || events[1]["severity"] != "error" {
+		t.Errorf("expected tool_error with error severity, got %v/%v", events[1]["eventType"], events[1]["severity"])
+	}
+	call, resp = events[0]["payload"].(map[string]any), events[1]["payload"].(map[string]any)
+	if call["arguments"].(map[string]any)["q"] != redactedPlaceholder || call["redacted"] != true {
+		t.Errorf("expected redacted arguments, got %v", call)
+	}
+	if resp["error"] != "upstream timeout" {
+		t.Errorf("expected error message to be kept, got %v", resp)
+	}
+}
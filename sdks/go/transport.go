@@ -0,0 +1,67 @@
+package agentlens
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// buildHTTPClient returns the *http.Client used for requests, the
+// transport beneath any middleware, and whether that transport was created
+// here rather than shared (http.DefaultTransport or the caller's). A client
+// supplied via WithHTTPClient is copied rather than mutated so that
+// middleware never leaks into the caller's client; WithProxy, WithTLSConfig
+// and WithConnectionPool only apply without one.
+func (cfg *clientConfig) buildHTTPClient() (hc *http.Client, base http.RoundTripper, owned bool) {
+	var c http.Client
+	if cfg.httpClient != nil {
+		c = *cfg.httpClient
+	} else {
+		c = http.Client{Timeout: cfg.timeout}
+		if cfg.proxyURL != "" || cfg.tlsConfig != nil || cfg.connPool != nil {
+			t := http.DefaultTransport.(*http.Transport).Clone()
+			if cfg.proxyURL != "" {
+				// Validate reports a malformed proxy URL.
+				if u, err := url.Parse(cfg.proxyURL); err == nil {
+					t.Proxy = http.ProxyURL(u)
+				}
+			}
+			if cfg.tlsConfig != nil {
+				t.TLSClientConfig = cfg.tlsConfig.Clone()
+			}
+			if p := cfg.connPool; p != nil {
+				if p.maxIdle > 0 {
+					t.MaxIdleConns = p.maxIdle
+				}
+				if p.maxIdlePerHost > 0 {
+					t.MaxIdleConnsPerHost = p.maxIdlePerHost
+				}
+				if p.maxConnsPerHost > 0 {
+					t.MaxConnsPerHost = p.maxConnsPerHost
+				}
+				if p.idleTimeout > 0 {
+					t.IdleConnTimeout = p.idleTimeout
+				}
+			}
+			c.Transport = t
+			owned = true
+		}
+	}
+	base = c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if len(cfg.middleware) > 0 {
+		rt := base
+		// Wrap in reverse so the first registered middleware sees the request first.
+		for i := len(cfg.middleware) - 1; i >= 0; i-- {
+			rt = cfg.middleware[i](rt)
+		}
+		c.Transport = rt
+	}
+	return &c, base, owned
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,172 @@
+package agentlens
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func headerMiddleware(name, value string, order *[]string) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			*order = append(*order, value)
+			r.Header.Set(name, value)
+			return next.RoundTrip(r)
+		})
+	}
+}
+
+func TestMiddlewareChainOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant-ID") != "t1" || r.Header.Get("X-Correlation-ID") != "c1" {
+			t.Errorf("missing middleware headers: %v", r.Header)
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	var order []string
+	c := NewClient(srv.URL, "key",
+		WithMiddleware(headerMiddleware("X-Tenant-ID", "t1", &order)),
+		WithMiddleware(headerMiddleware("X-Correlation-ID", "c1", &order)),
+	)
+	if _, err := c.Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "t1,c1" {
+		t.Errorf("expected registration order t1,c1, got %v", order)
+	}
+}
+
+func TestRequestModifier(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if got := r.Header.Get("X-Signature"); !strings.HasPrefix(got, "sig-agentlens-go/") {
+			t.Errorf("X-Signature = %q", got)
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	sign := func(r *http.Request) error {
+		r.Header.Set("X-Signature", "sig-"+r.Header.Get("User-Agent"))
+		return nil
+	}
+	c := NewClient(srv.URL, "key", WithRequestModifier(sign))
+	if _, err := c.Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	failing := NewClient(srv.URL, "key", WithRequestModifier(func(*http.Request) error { return errSigning }))
+	if _, err := failing.Health(context.Background()); !errors.Is(err, errSigning) {
+		t.Errorf("expected the modifier's error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 request, got %d", calls)
+	}
+}
+
+var errSigning = errors.New("no credentials")
+
+func TestMiddlewareWrapsCustomHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	var baseCalls int
+	base := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		baseCalls++
+		if r.Header.Get("X-Tenant-ID") != "t1" {
+			t.Errorf("middleware should run before custom transport")
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	var order []string
+	c := NewClient(srv.URL, "key", WithHTTPClient(base), WithMiddleware(headerMiddleware("X-Tenant-ID", "t1", &order)))
+	if _, err := c.Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if baseCalls != 1 {
+		t.Errorf("expected custom transport to be used, got %d calls", baseCalls)
+	}
+	if _, ok := base.Transport.(roundTripperFunc); !ok {
+		t.Error("caller's http.Client should not be mutated")
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer proxy.Close()
+
+	var order []string
+	c := NewClient("http://agentlens.invalid", "key",
+		WithProxy(proxy.URL),
+		WithMiddleware(headerMiddleware("X-Mw", "1", &order)),
+	)
+	if _, err := c.Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://agentlens.invalid/api/health" {
+		t.Errorf("expected request through proxy, got %v", proxied)
+	}
+	if len(order) != 1 {
+		t.Error("expected middleware to wrap the proxied transport")
+	}
+
+	if _, err := NewClientWithError("http://agentlens.invalid", "key", WithProxy("://bad")); err == nil {
+		t.Error("expected invalid proxy URL to be rejected")
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // silence the expected handshake failure
+	srv.StartTLS()
+	defer srv.Close()
+
+	if _, err := NewClient(srv.URL, "key", WithRetry(RetryConfig{})).Health(context.Background()); err == nil {
+		t.Fatal("expected self-signed certificate to be rejected by default")
+	}
+	tlsCfg := srv.Client().Transport.(*http.Transport).TLSClientConfig
+	if _, err := NewClient(srv.URL, "key", WithTLSConfig(tlsCfg)).Health(context.Background()); err != nil {
+		t.Fatalf("expected custom root CAs to be trusted: %v", err)
+	}
+}
+
+func TestWithConnectionPool(t *testing.T) {
+	c := NewClient("http://localhost:3400", "key", WithConnectionPool(200, 50, 0, time.Minute))
+	tr, ok := c.transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected an *http.Transport, got %T", c.transport)
+	}
+	if tr.MaxIdleConns != 200 || tr.MaxIdleConnsPerHost != 50 || tr.IdleConnTimeout != time.Minute {
+		t.Errorf("pool settings not applied: %d %d %v", tr.MaxIdleConns, tr.MaxIdleConnsPerHost, tr.IdleConnTimeout)
+	}
+	if tr.MaxConnsPerHost != 0 {
+		t.Errorf("zero should keep the default MaxConnsPerHost, got %d", tr.MaxConnsPerHost)
+	}
+	if tr == http.DefaultTransport {
+		t.Error("the default transport must not be modified")
+	}
+
+	custom := &http.Client{}
+	c = NewClient("http://localhost:3400", "key", WithHTTPClient(custom), WithConnectionPool(200, 50, 0, 0))
+	if c.transport != http.DefaultTransport {
+		t.Error("WithConnectionPool should be ignored with WithHTTPClient")
+	}
+}
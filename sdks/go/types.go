@@ -1,6 +1,18 @@
 package agentlens
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+// FailOpenStatus is embedded in result types to report whether the call that
+// produced them failed and had its error suppressed by fail-open mode.
+// Only populated when the client uses WithFailOpenSentinel.
+type FailOpenStatus struct {
+	Suppressed bool `json:"-"`
+}
+
+func (s *FailOpenStatus) markSuppressed() { s.Suppressed = true }
 
 // Event represents an AgentLens event.
 type Event struct {
@@ -27,14 +39,34 @@ type EventQuery struct {
 	Search    *string `json:"search,omitempty"`
 	Limit     *int    `json:"limit,omitempty"`
 	Offset    *int    `json:"offset,omitempty"`
-	Order     *string `json:"order,omitempty"`
+	// Cursor resumes from a previous page's NextCursor. It takes precedence
+	// over Offset on servers that support cursor pagination.
+	Cursor *string `json:"cursor,omitempty"`
+	Order  *string `json:"order,omitempty"`
+	// PayloadFilters matches exact values of top-level payload fields, e.g.
+	// {"model": "gpt-4"}; non-string values are compared in their fmt.Sprint
+	// form. Filters are ANDed. The server does not support them, so
+	// QueryEvents and EventsIterator apply them to each page they receive: a
+	// page may hold fewer than Limit events, and Total and HasMore describe
+	// the unfiltered results. CountEvents ignores them.
+	PayloadFilters map[string]string `json:"-"`
+	// MetadataFilters is like PayloadFilters for metadata fields.
+	MetadataFilters map[string]string `json:"-"`
+	// Fields is a comma-separated list of top-level event fields to return,
+	// e.g. "id,eventType,timestamp". The server ignores it and always
+	// returns full events; it is sent only for forward compatibility.
+	Fields *string `json:"fields,omitempty"`
 }
 
 // EventQueryResult is the response from QueryEvents.
 type EventQueryResult struct {
+	FailOpenStatus
 	Events  []Event `json:"events"`
 	Total   int     `json:"total"`
 	HasMore bool    `json:"hasMore"`
+	// NextCursor fetches the following page when passed as EventQuery.Cursor.
+	// Empty if the server does not support cursors or there are no more pages.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // Session represents an AgentLens session.
@@ -54,13 +86,21 @@ type SessionQuery struct {
 	Status  *string `json:"status,omitempty"`
 	From    *string `json:"from,omitempty"`
 	To      *string `json:"to,omitempty"`
-	Tags    *string `json:"tags,omitempty"`
 	Limit   *int    `json:"limit,omitempty"`
 	Offset  *int    `json:"offset,omitempty"`
+	// Tags selects sessions by tag, combined according to TagMatch.
+	Tags []string `json:"tags,omitempty"`
+	// TagMatch is TagMatchAny (the default: sessions with at least one of
+	// Tags) or TagMatchAll (sessions with every one of Tags). The server
+	// matches any tag, so TagMatchAll is applied client-side to each page:
+	// a page may hold fewer than Limit sessions, and Total and HasMore
+	// count any-tag matches.
+	TagMatch string `json:"tagMatch,omitempty"`
 }
 
 // SessionQueryResult is the response from GetSessions.
 type SessionQueryResult struct {
+	FailOpenStatus
 	Sessions []Session `json:"sessions"`
 	Total    int       `json:"total"`
 	HasMore  bool      `json:"hasMore"`
@@ -68,10 +108,25 @@ type SessionQueryResult struct {
 
 // TimelineResult is the response from GetSessionTimeline.
 type TimelineResult struct {
+	FailOpenStatus
 	Events     []Event `json:"events"`
 	ChainValid bool    `json:"chainValid"`
 }
 
+// SessionSummary is the response from GetSessionSummary.
+type SessionSummary struct {
+	FailOpenStatus
+	SessionID    string  `json:"sessionId"`
+	TotalCostUsd float64 `json:"totalCostUsd"`
+	TotalTokens  int     `json:"totalTokens"`
+	CallCount    int     `json:"callCount"`
+	ErrorCount   int     `json:"errorCount"`
+	DurationMs   float64 `json:"durationMs"`
+	// Computed is true when the summary was computed client-side from the
+	// session timeline because the server does not expose the summary endpoint.
+	Computed bool `json:"-"`
+}
+
 // Agent represents an AgentLens agent.
 type Agent struct {
 	ID            string         `json:"id"`
@@ -91,19 +146,46 @@ type HealthResult struct {
 
 // HealthScore represents a health score for an agent.
 type HealthScore struct {
-	AgentID    string   `json:"agentId"`
-	Score      float64  `json:"score"`
-	Components any      `json:"components,omitempty"`
-	Window     *int     `json:"window,omitempty"`
-	UpdatedAt  *string  `json:"updatedAt,omitempty"`
+	AgentID string  `json:"agentId"`
+	Score   float64 `json:"score"`
+	// OverallScore is the 0-100 weighted score reported by current servers.
+	OverallScore float64 `json:"overallScore"`
+	// RawComponents is the untyped components object sent by older
+	// servers. See Components for a typed view.
+	RawComponents any     `json:"components,omitempty"`
+	Window        *int    `json:"window,omitempty"`
+	UpdatedAt     *string `json:"updatedAt,omitempty"`
+	// Dimensions are the weighted sub-scores behind OverallScore. See
+	// Components for a typed view.
+	Dimensions []HealthDimension `json:"dimensions,omitempty"`
+}
+
+// HealthDimension is one weighted component of a HealthScore.
+type HealthDimension struct {
+	// Name is error_rate, cost_efficiency, tool_success, latency or
+	// completion_rate.
+	Name string `json:"name"`
+	// Score is 0-100.
+	Score float64 `json:"score"`
+	// Weight is the dimension's share of the overall score, 0-1.
+	Weight float64 `json:"weight"`
+	// RawValue is the underlying metric, e.g. the error rate.
+	RawValue    float64 `json:"rawValue"`
+	Description string  `json:"description"`
 }
 
 // HealthSnapshot represents a historical health snapshot.
 type HealthSnapshot struct {
-	AgentID    string  `json:"agentId"`
-	Score      float64 `json:"score"`
-	Components any     `json:"components,omitempty"`
-	Timestamp  string  `json:"timestamp"`
+	AgentID       string  `json:"agentId"`
+	Score         float64 `json:"score"`
+	RawComponents any     `json:"components,omitempty"`
+	Timestamp     string  `json:"timestamp"`
+	// Date and OverallScore are set by current servers, which record one
+	// snapshot per day.
+	Date         string  `json:"date,omitempty"`
+	OverallScore float64 `json:"overallScore,omitempty"`
+
+	scores *HealthComponents // per-dimension scores; see Components
 }
 
 // OptimizationOpts are options for optimization recommendations.
@@ -115,27 +197,72 @@ type OptimizationOpts struct {
 
 // OptimizationResult is the response from GetOptimizationRecommendations.
 type OptimizationResult struct {
-	Recommendations []any `json:"recommendations"`
+	FailOpenStatus
+	Recommendations []Recommendation `json:"recommendations"`
+	// TotalPotentialSavings is the estimated monthly saving in USD of
+	// applying every recommendation.
+	TotalPotentialSavings float64 `json:"totalPotentialSavings"`
+	// Period is the number of days analyzed.
+	Period        int `json:"period"`
+	AnalyzedCalls int `json:"analyzedCalls"`
 }
 
 // RecallQuery contains parameters for semantic search.
 type RecallQuery struct {
-	Query    string   `json:"query"`
-	Scope    *string  `json:"scope,omitempty"`
-	AgentID  *string  `json:"agentId,omitempty"`
-	From     *string  `json:"from,omitempty"`
-	To       *string  `json:"to,omitempty"`
-	Limit    *int     `json:"limit,omitempty"`
-	MinScore *float64 `json:"minScore,omitempty"`
+	Query string `json:"query"`
+	// Scope limits which sources are searched; empty means RecallScopeAll.
+	Scope    RecallScope `json:"scope,omitempty"`
+	AgentID  *string     `json:"agentId,omitempty"`
+	From     *string     `json:"from,omitempty"`
+	To       *string     `json:"to,omitempty"`
+	Limit    *int        `json:"limit,omitempty"`
+	MinScore *float64    `json:"minScore,omitempty"`
+	// EnforceMinScore also drops results below MinScore client-side, for
+	// servers that don't honour minScore.
+	EnforceMinScore bool `json:"-"`
+	// SortByScore sorts results by score, highest first, keeping the
+	// server's order among equal scores.
+	SortByScore bool `json:"-"`
+}
+
+// RecallMatch is a single Recall hit.
+type RecallMatch struct {
+	// SourceType is "event" or "session".
+	SourceType string `json:"sourceType"`
+	// SourceID is the ID of the matching event or session.
+	SourceID string `json:"sourceId"`
+	// Score is the cosine similarity, 0-1.
+	Score float64 `json:"score"`
+	// Text is the matched snippet.
+	Text     string         `json:"text"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// Raw is the match as returned by the server, including fields this
+	// version of the SDK does not know about.
+	Raw json.RawMessage `json:"-"`
+}
+
+// EventID returns SourceID if the match is an event, or "".
+func (m *RecallMatch) EventID() string {
+	if m.SourceType == "event" {
+		return m.SourceID
+	}
+	return ""
 }
 
 // RecallResult is the response from Recall.
 type RecallResult struct {
-	Results []any `json:"results"`
+	FailOpenStatus
+	// Results are sorted by score, highest first.
+	Results      []RecallMatch `json:"results"`
+	Query        string        `json:"query"`
+	TotalResults int           `json:"totalResults"`
+	// Raw is the full response body.
+	Raw json.RawMessage `json:"-"`
 }
 
 // ReflectQuery contains parameters for pattern analysis.
 type ReflectQuery struct {
+	// Analysis is one of the Analysis* constants, e.g. AnalysisErrorPatterns.
 	Analysis string  `json:"analysis"`
 	AgentID  *string `json:"agentId,omitempty"`
 	From     *string `json:"from,omitempty"`
@@ -144,9 +271,37 @@ type ReflectQuery struct {
 	Params   *string `json:"params,omitempty"`
 }
 
+// ReflectInsight is one finding from a Reflect analysis, such as an error
+// pattern or a frequent tool sequence.
+type ReflectInsight struct {
+	Type    string         `json:"type"`
+	Summary string         `json:"summary"`
+	Data    map[string]any `json:"data"`
+	// Confidence is 0-1.
+	Confidence float64 `json:"confidence"`
+	// Raw is the insight as returned by the server.
+	Raw json.RawMessage `json:"-"`
+}
+
+// ReflectMetadata describes the data a Reflect analysis covered.
+type ReflectMetadata struct {
+	SessionsAnalyzed int `json:"sessionsAnalyzed"`
+	EventsAnalyzed   int `json:"eventsAnalyzed"`
+	TimeRange        struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"timeRange"`
+}
+
 // ReflectResult is the response from Reflect.
 type ReflectResult struct {
-	Analysis any `json:"analysis"`
+	FailOpenStatus
+	// Analysis is the analysis type that was run.
+	Analysis string           `json:"analysis"`
+	Insights []ReflectInsight `json:"insights"`
+	Metadata ReflectMetadata  `json:"metadata"`
+	// Raw is the full response body.
+	Raw json.RawMessage `json:"-"`
 }
 
 // ContextQuery contains parameters for cross-session context.
@@ -159,9 +314,54 @@ type ContextQuery struct {
 	Limit   *int    `json:"limit,omitempty"`
 }
 
+// ContextKeyEvent is a notable event within a ContextSession.
+type ContextKeyEvent struct {
+	ID        string `json:"id"`
+	EventType string `json:"eventType"`
+	Summary   string `json:"summary"`
+	Timestamp string `json:"timestamp"`
+}
+
+// ContextSession is a past session relevant to a GetContext topic.
+type ContextSession struct {
+	SessionID      string            `json:"sessionId"`
+	AgentID        string            `json:"agentId"`
+	StartedAt      string            `json:"startedAt"`
+	EndedAt        *string           `json:"endedAt,omitempty"`
+	Summary        *string           `json:"summary,omitempty"`
+	RelevanceScore float64           `json:"relevanceScore"`
+	KeyEvents      []ContextKeyEvent `json:"keyEvents"`
+	// Raw is the session as returned by the server.
+	Raw json.RawMessage `json:"-"`
+}
+
+// ContextLesson is a stored lesson relevant to a GetContext topic.
+type ContextLesson struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+	Category string `json:"category"`
+	// Importance is low, normal, high, or critical.
+	Importance     string  `json:"importance"`
+	RelevanceScore float64 `json:"relevanceScore"`
+	// Raw is the lesson as returned by the server.
+	Raw json.RawMessage `json:"-"`
+}
+
 // ContextResult is the response from GetContext.
 type ContextResult struct {
-	Context any `json:"context"`
+	FailOpenStatus
+	Topic string `json:"topic"`
+	// Sessions are sorted by relevance, highest first.
+	Sessions      []ContextSession `json:"sessions"`
+	Lessons       []ContextLesson  `json:"lessons"`
+	TotalSessions int              `json:"totalSessions"`
+	Summary       *string          `json:"summary,omitempty"`
+	// Entries is Sessions flattened to one ContextEntry each, in the same
+	// order. Lessons are not included.
+	Entries []ContextEntry `json:"-"`
+	// Raw is the full response body.
+	Raw json.RawMessage `json:"-"`
 }
 
 // VerifyAuditParams contains parameters for audit verification.
@@ -181,6 +381,7 @@ type BrokenChainDetail struct {
 
 // VerificationReport is the response from VerifyAudit.
 type VerificationReport struct {
+	FailOpenStatus
 	Verified         bool               `json:"verified"`
 	VerifiedAt       string             `json:"verifiedAt"`
 	Range            *VerificationRange `json:"range"`
@@ -21,6 +21,7 @@ type GuardrailRule struct {
 
 // GuardrailRuleListResult is the response from ListGuardrails.
 type GuardrailRuleListResult struct {
+	FailOpenStatus
 	Rules []GuardrailRule `json:"rules"`
 }
 
@@ -62,6 +63,9 @@ type GuardrailState struct {
 	RuleID        string  `json:"ruleId"`
 	TriggerCount  int     `json:"triggerCount"`
 	LastTriggered *string `json:"lastTriggered,omitempty"`
+	// LastTriggeredAt is the RFC 3339 time of the last trigger as sent by
+	// current servers; older servers sent LastTriggered.
+	LastTriggeredAt *string `json:"lastTriggeredAt,omitempty"`
 }
 
 // GuardrailTriggerHistory represents a guardrail trigger event.
@@ -79,6 +83,7 @@ type GuardrailTriggerHistory struct {
 
 // GuardrailStatusResult is the response from GetGuardrailStatus.
 type GuardrailStatusResult struct {
+	FailOpenStatus
 	Rule           GuardrailRule             `json:"rule"`
 	State          *GuardrailState           `json:"state"`
 	RecentTriggers []GuardrailTriggerHistory `json:"recentTriggers"`
@@ -86,6 +91,7 @@ type GuardrailStatusResult struct {
 
 // GuardrailTriggerHistoryResult is the response from GetGuardrailHistory.
 type GuardrailTriggerHistoryResult struct {
+	FailOpenStatus
 	Triggers []GuardrailTriggerHistory `json:"triggers"`
 	Total    int                       `json:"total"`
 }
@@ -96,3 +102,53 @@ type GuardrailHistoryOpts struct {
 	Limit  *int    `json:"limit,omitempty"`
 	Offset *int    `json:"offset,omitempty"`
 }
+
+// GuardrailStatsOpts are options for guardrail trigger statistics.
+type GuardrailStatsOpts struct {
+	AgentID *string `json:"agentId,omitempty"`
+	From    *string `json:"from,omitempty"`
+	To      *string `json:"to,omitempty"`
+}
+
+// GuardrailRuleStats aggregates triggers for a single guardrail rule.
+type GuardrailRuleStats struct {
+	RuleID        string  `json:"ruleId"`
+	RuleName      string  `json:"ruleName"`
+	TriggerCount  int     `json:"triggerCount"`
+	LastTriggered *string `json:"lastTriggered,omitempty"`
+	// ActionCounts maps each recorded action result (e.g. "pause_agent", "dry_run") to its count.
+	ActionCounts map[string]int `json:"actionCounts"`
+	// LastTrigger is the most recent trigger in the window.
+	LastTrigger *GuardrailTriggerHistory `json:"lastTrigger,omitempty"`
+}
+
+// GuardrailStatsResult is the response from GetGuardrailStats.
+type GuardrailStatsResult struct {
+	FailOpenStatus
+	Rules         []GuardrailRuleStats `json:"rules"`
+	TotalTriggers int                  `json:"totalTriggers"`
+	From          *string              `json:"from,omitempty"`
+	To            *string              `json:"to,omitempty"`
+}
+
+// GuardrailImportOpts are options for ImportGuardrails.
+type GuardrailImportOpts struct {
+	// Upsert updates existing rules with the same Name instead of skipping them.
+	Upsert bool
+}
+
+// GuardrailImportError describes a rule that failed to import.
+type GuardrailImportError struct {
+	// Index is the rule's position in the input slice.
+	Index int
+	Name  string
+	Err   error
+}
+
+// GuardrailImportResult is the response from ImportGuardrails.
+type GuardrailImportResult struct {
+	Created int
+	Updated int
+	Skipped int
+	Errors  []GuardrailImportError
+}
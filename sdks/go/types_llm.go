@@ -1,5 +1,7 @@
 package agentlens
 
+import "time"
+
 // LlmMessage represents a message in an LLM conversation.
 type LlmMessage struct {
 	Role    string `json:"role"`
@@ -43,6 +45,13 @@ type LogLlmCallParams struct {
 	Parameters   map[string]any `json:"parameters,omitempty"`
 	Tools        []LlmTool     `json:"tools,omitempty"`
 	Redact       bool           `json:"redact,omitempty"`
+	// ProviderRequestID is the upstream provider's request ID (e.g. OpenAI's
+	// x-request-id), recorded as "providerRequestId" in the llm_response
+	// event's metadata for cross-referencing provider dashboards.
+	ProviderRequestID *string `json:"providerRequestId,omitempty"`
+	// ProviderMetadata is merged into the llm_response event's metadata.
+	// Neither field is affected by Redact.
+	ProviderMetadata map[string]any `json:"providerMetadata,omitempty"`
 }
 
 // LlmAnalyticsParams contains parameters for LLM analytics queries.
@@ -53,6 +62,15 @@ type LlmAnalyticsParams struct {
 	Model       *string `json:"model,omitempty"`
 	Provider    *string `json:"provider,omitempty"`
 	Granularity *string `json:"granularity,omitempty"`
+	// Window is the chunk size used by GetLlmAnalyticsChunked and
+	// StreamLlmAnalytics (default 7 days). Ignored by GetLlmAnalytics.
+	Window time.Duration `json:"-"`
+	// GroupBy asks the server to also break the results down by the value
+	// of this event metadata key, e.g. "team", returned as ByTag. The key
+	// must be on the llm_response events, e.g. via WithDefaultMetadata or
+	// WithMetadataInjector. Servers without grouping support ignore it and
+	// return no ByTag.
+	GroupBy *string `json:"groupBy,omitempty"`
 }
 
 // LlmAnalyticsSummary contains aggregate LLM analytics.
@@ -86,9 +104,24 @@ type LlmAnalyticsByTime struct {
 	AvgLatencyMs float64 `json:"avgLatencyMs"`
 }
 
+// LlmAnalyticsByTag contains analytics broken down by the value of the
+// LlmAnalyticsParams.GroupBy metadata key.
+type LlmAnalyticsByTag struct {
+	// Value is the key's value; calls without the key have an empty Value.
+	Value        string  `json:"value"`
+	Calls        int     `json:"calls"`
+	CostUsd      float64 `json:"costUsd"`
+	InputTokens  int     `json:"inputTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+}
+
 // LlmAnalyticsResult is the response from GetLlmAnalytics.
 type LlmAnalyticsResult struct {
+	FailOpenStatus
 	Summary LlmAnalyticsSummary   `json:"summary"`
 	ByModel []LlmAnalyticsByModel `json:"byModel"`
 	ByTime  []LlmAnalyticsByTime  `json:"byTime"`
+	// ByTag is set when LlmAnalyticsParams.GroupBy is.
+	ByTag []LlmAnalyticsByTag `json:"byTag,omitempty"`
 }
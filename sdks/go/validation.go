@@ -0,0 +1,62 @@
+package agentlens
+
+import (
+	"fmt"
+	"time"
+)
+
+// FieldError describes a single invalid field. It encodes as the server's
+// validation error details do, {"path": ..., "message": ...}, so
+// ValidationError.FieldErrors reads client- and server-side errors alike.
+type FieldError struct {
+	Field   string `json:"path"`
+	Message string `json:"message"`
+}
+
+// newFieldValidationError builds a client-side ValidationError for one field.
+func newFieldValidationError(field, message string) *ValidationError {
+	return &ValidationError{newAPIError(
+		fmt.Sprintf("invalid %s: %s", field, message), 0, "VALIDATION_ERROR",
+		[]FieldError{{Field: field, Message: message}},
+	)}
+}
+
+// Validate checks the event for problems the server would reject: a missing
+// SessionID or EventType, an unknown Severity, or a Timestamp that is not
+// RFC 3339. An empty Severity is allowed and defaults server-side.
+// Returns a *ValidationError naming the offending field.
+func (e *Event) Validate() error {
+	if field, msg := e.invalidField(); field != "" {
+		return newFieldValidationError(field, msg)
+	}
+	return nil
+}
+
+func (e *Event) invalidField() (field, message string) {
+	if e.SessionID == "" {
+		return "sessionId", "is required"
+	}
+	if e.EventType == "" {
+		return "eventType", "is required"
+	}
+	if e.Severity != "" && !ValidSeverity(e.Severity) {
+		return "severity", fmt.Sprintf("unknown severity %q (want debug, info, warn, error, or critical)", e.Severity)
+	}
+	if e.Timestamp != "" {
+		if _, err := time.Parse(time.RFC3339Nano, e.Timestamp); err != nil {
+			return "timestamp", "must be RFC 3339"
+		}
+	}
+	return "", ""
+}
+
+// validateEvents validates each event, naming the batch index of the first
+// invalid one, e.g. "events[3].sessionId".
+func validateEvents(events []Event) error {
+	for i := range events {
+		if field, msg := events[i].invalidField(); field != "" {
+			return newFieldValidationError(fmt.Sprintf("events[%d].%s", i, field), msg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package agentlens
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventValidate(t *testing.T) {
+	valid := Event{SessionID: "s1", EventType: "custom", Severity: "info", Timestamp: "2024-01-01T00:00:00Z"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid event, got %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		event Event
+		field string
+	}{
+		{"missing session", Event{EventType: "custom"}, "sessionId"},
+		{"missing type", Event{SessionID: "s1"}, "eventType"},
+		{"bad severity", Event{SessionID: "s1", EventType: "custom", Severity: "fatal"}, "severity"},
+		{"bad timestamp", Event{SessionID: "s1", EventType: "custom", Timestamp: "yesterday"}, "timestamp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.event.Validate()
+			var vErr *ValidationError
+			if !errors.As(err, &vErr) {
+				t.Fatalf("expected ValidationError, got %v", err)
+			}
+			if !strings.Contains(vErr.Message, tt.field) {
+				t.Errorf("expected message to name %s, got %q", tt.field, vErr.Message)
+			}
+			if vErr.FieldErrors()[tt.field] == "" {
+				t.Errorf("expected FieldErrors to include %s, got %v", tt.field, vErr.FieldErrors())
+			}
+		})
+	}
+}
+
+func TestValidSeverity(t *testing.T) {
+	for _, s := range []string{SeverityDebug, SeverityInfo, SeverityWarn, SeverityError, SeverityCritical} {
+		if !ValidSeverity(s) {
+			t.Errorf("ValidSeverity(%q) = false", s)
+		}
+		e := Event{SessionID: "s1", EventType: EventTypeCustom, Severity: s}
+		if err := e.Validate(); err != nil {
+			t.Errorf("severity %q: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "fatal", "INFO"} {
+		if ValidSeverity(s) {
+			t.Errorf("ValidSeverity(%q) = true", s)
+		}
+	}
+}
+
+func TestSendEventsClientValidation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid batch should not reach the server")
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", WithClientValidation())
+	err := c.SendEvents(context.Background(), []Event{
+		{SessionID: "s1", EventType: "custom"},
+		{SessionID: "s1", EventType: "custom", Severity: "loud"},
+	})
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if !strings.Contains(vErr.Message, "events[1].severity") {
+		t.Errorf("expected message to name events[1].severity, got %q", vErr.Message)
+	}
+}
+
+func TestBatchValidation(t *testing.T) {
+	var sent []Event
+	var reported error
+	bs := NewBatchSender(func(ctx context.Context, events []Event) error {
+		sent = append(sent, events...)
+		return nil
+	}, WithBatchValidation(), WithFlushInterval(time.Hour), WithBatchOnError(func(err error) { reported = err }))
+
+	bs.Enqueue(Event{SessionID: "s1", EventType: "custom"})
+	bs.Enqueue(Event{EventType: "custom"})
+	bs.Shutdown(context.Background())
+
+	if len(sent) != 1 {
+		t.Errorf("expected only the valid event to be sent, got %d", len(sent))
+	}
+	if reported == nil {
+		t.Error("expected invalid event to be reported")
+	}
+}
@@ -0,0 +1,105 @@
+package agentlens
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Headers the server sets on signed webhook deliveries when
+// AGENTLENS_WEBHOOK_SIGNING_SECRET is configured.
+const (
+	WebhookSignatureHeader = "X-AgentLens-Signature"
+	WebhookTimestampHeader = "X-AgentLens-Timestamp"
+)
+
+// ErrInvalidWebhookSignature is returned when a webhook signature is missing
+// or does not match the payload.
+var ErrInvalidWebhookSignature = errors.New("agentlens: invalid webhook signature")
+
+// VerifyWebhookSignature checks a webhook delivery against the
+// X-AgentLens-Signature header value ("sha256=<hex>"). Deliveries through
+// notification webhook channels (guardrail action notify_channel) are
+// signed; direct notify_webhook action calls are not. The server signs
+// "<timestamp>.<body>", so the X-AgentLens-Timestamp header value must be
+// passed as well; rejecting old timestamps guards against replays. payload
+// must be the raw request body. The comparison is constant-time.
+func VerifyWebhookSignature(payload []byte, header, timestamp, secret string) error {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return ErrInvalidWebhookSignature
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// guardrailWebhookPayload covers both bodies the server POSTs for a
+// guardrail: the unsigned notify_webhook action payload (guardrailId, ...)
+// and the notification payload delivered through a webhook channel by
+// notify_channel (ruleId, ..., metadata), which is signed.
+type guardrailWebhookPayload struct {
+	GuardrailID        string         `json:"guardrailId"`
+	GuardrailName      string         `json:"guardrailName"`
+	ConditionType      string         `json:"conditionType"`
+	ConditionValue     *float64       `json:"conditionValue"`
+	ConditionThreshold *float64       `json:"conditionThreshold"`
+	RuleID             string         `json:"ruleId"`
+	RuleName           string         `json:"ruleName"`
+	Metadata           map[string]any `json:"metadata"`
+	Message            string         `json:"message"`
+	AgentID            *string        `json:"agentId"`
+	TriggeredAt        string         `json:"triggeredAt"`
+}
+
+// ParseGuardrailWebhook decodes a guardrail callback body, either from a
+// notify_webhook action or from a notify_channel webhook channel. The
+// condition type, value, threshold and message are returned in Details.
+func ParseGuardrailWebhook(body []byte) (*GuardrailTriggerHistory, error) {
+	var p guardrailWebhookPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("agentlens: parse guardrail webhook: %w", err)
+	}
+	h := &GuardrailTriggerHistory{AgentID: p.AgentID, Timestamp: p.TriggeredAt}
+	details := map[string]any{"message": p.Message}
+	switch {
+	case p.GuardrailID != "":
+		h.RuleID, h.RuleName, h.Action = p.GuardrailID, p.GuardrailName, "notify_webhook"
+		details["conditionType"] = p.ConditionType
+		if p.ConditionValue != nil {
+			details["conditionValue"] = *p.ConditionValue
+		}
+		if p.ConditionThreshold != nil {
+			details["conditionThreshold"] = *p.ConditionThreshold
+		}
+	case p.RuleID != "":
+		h.RuleID, h.RuleName, h.Action = p.RuleID, p.RuleName, "notify_channel"
+		for k, v := range p.Metadata {
+			switch k {
+			case "currentValue":
+				details["conditionValue"] = v
+			case "threshold":
+				details["conditionThreshold"] = v
+			default:
+				details[k] = v
+			}
+		}
+	default:
+		return nil, errors.New("agentlens: parse guardrail webhook: missing guardrailId or ruleId")
+	}
+	h.Details = details
+	return h, nil
+}
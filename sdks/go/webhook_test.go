@@ -0,0 +1,58 @@
+package agentlens
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"guardrailId":"g1"}`)
+	ts := "2024-01-01T00:00:00.000Z"
+	// Generated with the server's webhookSignatureHeaders (Node crypto).
+	header := "sha256=090ea790d73af97f0183ea32ed4ed3555799223e683097ea9264a0a6768a5fe9"
+
+	if err := VerifyWebhookSignature(body, header, ts, "s3cret"); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	for name, tc := range map[string]struct{ body, header, ts, secret string }{
+		"tampered body": {`{"guardrailId":"g2"}`, header, ts, "s3cret"},
+		"wrong secret":  {string(body), header, ts, "other"},
+		"wrong ts":      {string(body), header, "2024-01-01T00:00:01.000Z", "s3cret"},
+		"no prefix":     {string(body), header[len("sha256="):], ts, "s3cret"},
+		"empty":         {string(body), "", ts, "s3cret"},
+	} {
+		if err := VerifyWebhookSignature([]byte(tc.body), tc.header, tc.ts, tc.secret); !errors.Is(err, ErrInvalidWebhookSignature) {
+			t.Errorf("%s: expected ErrInvalidWebhookSignature, got %v", name, err)
+		}
+	}
+}
+
+func TestParseGuardrailWebhook(t *testing.T) {
+	body := []byte(`{"guardrailId":"g1","guardrailName":"budget","conditionType":"cost_limit","conditionValue":12.5,"conditionThreshold":10,"message":"Daily cost exceeds limit","agentId":"a1","triggeredAt":"2024-01-01T00:00:00.000Z"}`)
+	h, err := ParseGuardrailWebhook(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.RuleID != "g1" || h.RuleName != "budget" || h.AgentID == nil || *h.AgentID != "a1" || h.Timestamp != "2024-01-01T00:00:00.000Z" {
+		t.Errorf("unexpected trigger: %+v", h)
+	}
+	if h.Details["conditionValue"] != 12.5 || h.Details["conditionThreshold"] != 10.0 || h.Details["conditionType"] != "cost_limit" {
+		t.Errorf("unexpected details: %+v", h.Details)
+	}
+
+	channel := []byte(`{"source":"guardrail","severity":"critical","title":"Guardrail: budget","message":"over","metadata":{"conditionType":"cost_limit","currentValue":12.5,"threshold":10},"triggeredAt":"2024-01-01T00:00:00.000Z","ruleId":"g1","ruleName":"budget","agentId":"a1"}`)
+	h, err = ParseGuardrailWebhook(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.RuleID != "g1" || h.Action != "notify_channel" || h.Details["conditionValue"] != 12.5 || h.Details["conditionType"] != "cost_limit" {
+		t.Errorf("unexpected channel trigger: %+v", h)
+	}
+
+	if _, err := ParseGuardrailWebhook([]byte(`{}`)); err == nil {
+		t.Error("expected error for payload without guardrailId")
+	}
+	if _, err := ParseGuardrailWebhook([]byte(`not json`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}